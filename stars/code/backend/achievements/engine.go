@@ -0,0 +1,130 @@
+package achievements
+
+import (
+	"context"
+	"errors"
+	"starpool/models"
+	"starpool/store"
+	"time"
+)
+
+// Badge 一个成就徽章的状态
+type Badge struct {
+	Key         string     `json:"key"`         // 徽章标识
+	Name        string     `json:"name"`        // 徽章名称
+	Description string     `json:"description"` // 获得条件的说明
+	Target      int        `json:"target"`      // 获得徽章所需的进度
+	Progress    int        `json:"progress"`    // 当前进度，不超过 Target
+	Earned      bool       `json:"earned"`      // 是否已获得
+	EarnedAt    *time.Time `json:"earned_at"`   // 获得时间，未获得时为空
+}
+
+// Event 触发成就评估的事件
+type Event struct {
+	GoalID int         // 创建或评分的目标ID，为0时评估用户所有进行中的目标
+	Date   models.Date // 评分日期或今天，评估连续评分类成就时只读取该日期前后有限窗口内的评分
+}
+
+// Engine 根据用户数据评估成就规则并保存获得的徽章
+type Engine struct {
+	Goals           store.GoalStore        // 目标存储
+	Ratings         store.RatingStore      // 每日评分存储
+	Achievements    store.AchievementStore // 成就存储
+	StreakMinRating int                    // 计入连续评分天数的最低评分
+}
+
+// Evaluate 在创建目标或评分后评估用户尚未获得的成就，保存并返回本次新获得的徽章
+// 只计算尚未获得的规则用到的数据，已获得所有徽章时不读取目标和评分
+func (e *Engine) Evaluate(ctx context.Context, userID int, event Event) ([]Badge, error) {
+	earned, err := e.earned(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	facts := e.facts(ctx, userID, event)
+
+	badges := []Badge{}
+	for _, rule := range rules {
+		if _, ok := earned[rule.Key]; ok {
+			continue
+		}
+		progress, err := rule.Progress(facts)
+		if err != nil {
+			return nil, err
+		}
+		if progress < rule.Target {
+			continue
+		}
+		achievement := models.Achievement{UserID: userID, Badge: rule.Key}
+		if err := e.Achievements.AddAchievement(ctx, &achievement); err != nil {
+			// 并发请求已经保存了该徽章
+			if errors.Is(err, store.ErrDuplicate) {
+				continue
+			}
+			return nil, err
+		}
+		badges = append(badges, newBadge(rule, progress, &achievement.EarnedAt))
+	}
+	return badges, nil
+}
+
+// Badges 按规则顺序返回用户所有徽章的状态和进度，已获得的徽章进度为目标值
+// 尚未获得的连续评分类徽章按截至 today 的评分计算进度
+func (e *Engine) Badges(ctx context.Context, userID int, today models.Date) ([]Badge, error) {
+	earned, err := e.earned(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	facts := e.facts(ctx, userID, Event{Date: today})
+
+	badges := make([]Badge, 0, len(rules))
+	for _, rule := range rules {
+		if earnedAt, ok := earned[rule.Key]; ok {
+			badges = append(badges, newBadge(rule, rule.Target, &earnedAt))
+			continue
+		}
+		progress, err := rule.Progress(facts)
+		if err != nil {
+			return nil, err
+		}
+		badges = append(badges, newBadge(rule, progress, nil))
+	}
+	return badges, nil
+}
+
+// earned 返回用户已获得的徽章及获得时间
+func (e *Engine) earned(ctx context.Context, userID int) (map[string]time.Time, error) {
+	achievements, err := e.Achievements.ListAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	earned := make(map[string]time.Time, len(achievements))
+	for _, achievement := range achievements {
+		earned[achievement.Badge] = achievement.EarnedAt
+	}
+	return earned, nil
+}
+
+// facts 创建按需读取用户数据的 Facts
+func (e *Engine) facts(ctx context.Context, userID int, event Event) *Facts {
+	return &Facts{
+		ctx:             ctx,
+		engine:          e,
+		userID:          userID,
+		event:           event,
+		streakMinRating: max(e.StreakMinRating, 1),
+		totals:          make(map[int]models.RatingTotal),
+	}
+}
+
+// newBadge 根据规则和进度创建徽章状态
+func newBadge(rule Rule, progress int, earnedAt *time.Time) Badge {
+	return Badge{
+		Key:         rule.Key,
+		Name:        rule.Name,
+		Description: rule.Description,
+		Target:      rule.Target,
+		Progress:    min(progress, rule.Target),
+		Earned:      earnedAt != nil,
+		EarnedAt:    earnedAt,
+	}
+}
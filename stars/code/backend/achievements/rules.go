@@ -0,0 +1,283 @@
+package achievements
+
+import (
+	"context"
+	"sort"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+	"time"
+)
+
+// Rule 一条成就规则，Progress 根据用户数据计算当前进度，进度达到 Target 时获得徽章
+// 新增成就只需在 rules 中追加一条规则，评估时机和接口无需改动；
+// Progress 通过 Facts 按需读取数据，只应使用聚合查询或有限窗口内的评分，使评估成本不随历史数据增长
+type Rule struct {
+	Key         string                      // 徽章标识，保存到数据库，发布后不能修改
+	Name        string                      // 徽章名称
+	Description string                      // 获得条件的说明
+	Target      int                         // 获得徽章所需的进度
+	Progress    func(f *Facts) (int, error) // 计算当前进度
+}
+
+// rules 所有成就规则，按展示顺序排列
+var rules = []Rule{
+	{
+		Key:         "first_goal",
+		Name:        "启程",
+		Description: "创建第一个目标",
+		Target:      1,
+		Progress:    func(f *Facts) (int, error) { return f.GoalCount() },
+	},
+	{
+		Key:         "first_five_star",
+		Name:        "完美一天",
+		Description: "第一次给目标打出5星",
+		Target:      1,
+		Progress: func(f *Facts) (int, error) {
+			total, err := f.RatingTotal(5)
+			return total.Count, err
+		},
+	},
+	streakRule(7, "坚持一周"),
+	streakRule(30, "坚持一月"),
+	streakRule(100, "百日坚持"),
+	{
+		Key:         "stars_100",
+		Name:        "百星",
+		Description: "累计获得100颗评分星",
+		Target:      100,
+		Progress: func(f *Facts) (int, error) {
+			total, err := f.RatingTotal(1)
+			return total.Stars, err
+		},
+	},
+	{
+		Key:         "category_week",
+		Name:        "全面发展",
+		Description: "连续7天为某个类别下所有进行中的目标评分",
+		Target:      7,
+		Progress:    func(f *Facts) (int, error) { return f.LongestCategoryRun() },
+	},
+}
+
+// streakRule 创建连续评分天数的成就规则
+func streakRule(days int, name string) Rule {
+	return Rule{
+		Key:         "streak_" + strconv.Itoa(days),
+		Name:        name,
+		Description: "任一目标按计划连续评分" + strconv.Itoa(days) + "天",
+		Target:      days,
+		Progress:    func(f *Facts) (int, error) { return f.LongestStreak() },
+	}
+}
+
+// Rules 返回所有成就规则
+func Rules() []Rule {
+	return rules
+}
+
+// 评估时读取评分明细的窗口，即触发日期前后的天数
+const (
+	// streakWindowDays 最长的连续评分成就为100天，每周只计划一天时跨越100周
+	streakWindowDays = 7 * 100
+	// categoryWindowDays 全面发展成就需要连续7天
+	categoryWindowDays = 7 - 1
+)
+
+// maxGoals 评估用户所有进行中的目标时最多读取的目标数
+const maxGoals = 500
+
+// Facts 评估成就规则所需的用户数据，每项数据在规则第一次用到时才查询并缓存
+// 计数和星数合计使用聚合查询，连续评分类数据只读取触发日期前后有限窗口内的评分：
+// 触发事件指定了目标时只计算该目标及其类别，否则计算用户所有进行中的目标
+type Facts struct {
+	ctx             context.Context
+	engine          *Engine
+	userID          int
+	event           Event
+	streakMinRating int // 计入连续评分天数的最低评分
+
+	goalCount   *int
+	totals      map[int]models.RatingTotal // 最低评分 -> 评分次数和星数合计
+	streak      *int
+	categoryRun *int
+	goal        *models.StarGoal // 触发事件的目标
+}
+
+// GoalCount 返回用户的目标数（包括所有状态）
+func (f *Facts) GoalCount() (int, error) {
+	if f.goalCount == nil {
+		page, err := f.engine.Goals.ListGoals(f.ctx, store.GoalQuery{OwnerID: f.userID, Sort: store.GoalSortCreatedAt, Limit: 1})
+		if err != nil {
+			return 0, err
+		}
+		f.goalCount = &page.Total
+	}
+	return *f.goalCount, nil
+}
+
+// RatingTotal 返回用户评分不低于 minRating 的评分次数和星数合计
+func (f *Facts) RatingTotal(minRating int) (models.RatingTotal, error) {
+	if total, ok := f.totals[minRating]; ok {
+		return total, nil
+	}
+	total, err := f.engine.Ratings.SumUserRatings(f.ctx, f.userID, minRating)
+	if err != nil {
+		return total, err
+	}
+	f.totals[minRating] = total
+	return total, nil
+}
+
+// LongestStreak 返回触发日期前后窗口内最长的连续评分天数，按各目标的计划计算
+func (f *Facts) LongestStreak() (int, error) {
+	if f.streak != nil {
+		return *f.streak, nil
+	}
+	from := models.Date{Time: f.event.Date.AddDate(0, 0, -streakWindowDays)}
+	to := models.Date{Time: f.event.Date.AddDate(0, 0, streakWindowDays)}
+
+	var goals []models.StarGoal
+	var ratings []models.DailyRating
+	if f.event.GoalID != 0 {
+		goal, err := f.eventGoal()
+		if err != nil {
+			return 0, err
+		}
+		if ratings, err = f.engine.Ratings.ListDailyRatings(f.ctx, goal.ID, from, to); err != nil {
+			return 0, err
+		}
+		goals = []models.StarGoal{*goal}
+	} else {
+		var err error
+		if goals, err = f.activeGoals(""); err != nil {
+			return 0, err
+		}
+		if ratings, err = f.engine.Ratings.ListUserRatings(f.ctx, f.userID, from, to); err != nil {
+			return 0, err
+		}
+	}
+	streak := longestStreak(goals, ratings, f.streakMinRating)
+	f.streak = &streak
+	return streak, nil
+}
+
+// LongestCategoryRun 返回触发日期前后窗口内，某个类别下所有进行中的目标每天都有评分的最长连续天数
+func (f *Facts) LongestCategoryRun() (int, error) {
+	if f.categoryRun != nil {
+		return *f.categoryRun, nil
+	}
+	category := ""
+	if f.event.GoalID != 0 {
+		goal, err := f.eventGoal()
+		if err != nil {
+			return 0, err
+		}
+		if goal.Category == "" || goal.Status != models.GoalStatusActive {
+			f.categoryRun = new(int)
+			return 0, nil
+		}
+		category = goal.Category
+	}
+	goals, err := f.activeGoals(category)
+	if err != nil {
+		return 0, err
+	}
+
+	from := models.Date{Time: f.event.Date.AddDate(0, 0, -categoryWindowDays)}
+	to := models.Date{Time: f.event.Date.AddDate(0, 0, categoryWindowDays)}
+	ratings, err := f.engine.Ratings.ListUserRatings(f.ctx, f.userID, from, to)
+	if err != nil {
+		return 0, err
+	}
+	run := longestCategoryRun(goals, ratings)
+	f.categoryRun = &run
+	return run, nil
+}
+
+// eventGoal 返回触发事件的目标
+func (f *Facts) eventGoal() (*models.StarGoal, error) {
+	if f.goal == nil {
+		goal, err := f.engine.Goals.GetGoal(f.ctx, f.event.GoalID)
+		if err != nil {
+			return nil, err
+		}
+		f.goal = goal
+	}
+	return f.goal, nil
+}
+
+// activeGoals 返回用户进行中的目标，category 不为空时只返回该类别的目标
+func (f *Facts) activeGoals(category string) ([]models.StarGoal, error) {
+	page, err := f.engine.Goals.ListGoals(f.ctx, store.GoalQuery{
+		OwnerID:  f.userID,
+		Category: category,
+		Statuses: []string{models.GoalStatusActive},
+		Sort:     store.GoalSortCreatedAt,
+		Limit:    maxGoals,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Goals, nil
+}
+
+// longestStreak 返回各目标中最长的连续评分天数，ratings 按日期升序
+func longestStreak(goals []models.StarGoal, ratings []models.DailyRating, minRating int) int {
+	dates := make(map[int][]models.Date)
+	for _, r := range ratings {
+		if r.Rating >= minRating {
+			dates[r.GoalID] = append(dates[r.GoalID], r.Date)
+		}
+	}
+	longest := 0
+	for _, goal := range goals {
+		if len(dates[goal.ID]) > 0 {
+			longest = max(longest, models.LongestStreak(goal.Schedule.Streaks(dates[goal.ID])))
+		}
+	}
+	return longest
+}
+
+// longestCategoryRun 返回某个类别下所有目标每天都有评分的最长连续天数，goals 为进行中的目标
+func longestCategoryRun(goals []models.StarGoal, ratings []models.DailyRating) int {
+	categories := make(map[string]map[int]bool)
+	for _, goal := range goals {
+		if goal.Category == "" {
+			continue
+		}
+		if categories[goal.Category] == nil {
+			categories[goal.Category] = make(map[int]bool)
+		}
+		categories[goal.Category][goal.ID] = true
+	}
+
+	longest := 0
+	for _, goalIDs := range categories {
+		// 统计每天评分的类别内目标数，等于目标总数的日期即为全部评分
+		rated := make(map[time.Time]int)
+		for _, r := range ratings {
+			if goalIDs[r.GoalID] {
+				rated[r.Date.Time]++
+			}
+		}
+		days := []time.Time{}
+		for day, count := range rated {
+			if count == len(goalIDs) {
+				days = append(days, day)
+			}
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+		run := 0
+		for i, day := range days {
+			if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+				run++
+			} else {
+				run = 1
+			}
+			longest = max(longest, run)
+		}
+	}
+	return longest
+}
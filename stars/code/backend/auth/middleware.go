@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"net/http"
+	"starpool/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserKey 当前用户在 gin.Context 中的键
+const currentUserKey = "currentUser"
+
+// RequireAuth 返回校验 Authorization: Bearer 访问令牌的中间件
+// 校验通过后将当前用户写入上下文，否则返回401并终止请求
+func RequireAuth(tokens *TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未登录或缺少访问令牌"})
+			return
+		}
+
+		user, err := tokens.Parse(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": ErrInvalidToken.Error()})
+			return
+		}
+
+		c.Set(currentUserKey, user)
+		c.Next()
+	}
+}
+
+// CurrentUser 返回 RequireAuth 写入上下文的当前用户，未经认证的请求返回 nil
+func CurrentUser(c *gin.Context) *models.User {
+	value, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil
+	}
+	user, _ := value.(*models.User)
+	return user
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"starpool/models"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken 表示访问令牌无效或已过期
+var ErrInvalidToken = errors.New("访问令牌无效或已过期")
+
+// Claims 访问令牌中携带的声明，Subject 为用户ID
+type Claims struct {
+	Username string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager 负责签发和校验 HS256 签名的访问令牌
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokenManager 使用签名密钥和令牌有效期创建 TokenManager
+func NewTokenManager(secret []byte, ttl time.Duration) *TokenManager {
+	return &TokenManager{secret: secret, ttl: ttl}
+}
+
+// Issue 为用户签发访问令牌，返回令牌和过期时间
+func (m *TokenManager) Issue(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+	claims := Claims{
+		Username: user.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Parse 校验访问令牌的签名和有效期，返回令牌中的用户
+func (m *TokenManager) Parse(tokenString string) (*models.User, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &models.User{ID: id, Username: claims.Username}, nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"crypto/rand"
+	"log"
+	"time"
+)
+
+// JWTSecret 返回签发访问令牌的密钥，由 JWT_SECRET 指定
+// 未设置时生成随机密钥，此时服务重启后已签发的令牌全部失效
+func JWTSecret() []byte {
+	if secret := getEnv("JWT_SECRET", ""); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Println("警告: 未设置 JWT_SECRET，使用随机生成的密钥，重启后需要重新登录")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatal("生成JWT密钥失败: ", err)
+	}
+	return secret
+}
+
+// JWTTTL 返回访问令牌的有效期，由 JWT_TTL 指定（如 24h），默认24小时
+func JWTTTL() time.Duration {
+	ttl, err := time.ParseDuration(getEnv("JWT_TTL", "24h"))
+	if err != nil || ttl <= 0 {
+		log.Fatalf("无效的 JWT_TTL: %s", getEnv("JWT_TTL", ""))
+	}
+	return ttl
+}
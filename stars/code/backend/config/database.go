@@ -7,23 +7,27 @@ import (
 	"os"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 var DB *sql.DB
 
-func ConnectDB() {
-	// 从环境变量获取数据库配置
-	dbUser := getEnv("DB_USER", "root")
-	dbPass := getEnv("DB_PASSWORD", "rootpassword")
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "3306")
-	dbName := getEnv("DB_NAME", "starpool")
+// DBDriver 当前使用的数据库驱动，取值为 mysql 或 sqlite
+var DBDriver string
 
-	// 创建数据库连接字符串
-	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&charset=utf8mb4&collation=utf8mb4_unicode_ci", dbUser, dbPass, dbHost, dbPort, dbName)
+// ConnectDB 根据 DB_DRIVER 环境变量连接 MySQL（默认）或 SQLite 数据库
+func ConnectDB() {
+	DBDriver = getEnv("DB_DRIVER", "mysql")
 
 	var err error
-	DB, err = sql.Open("mysql", dataSourceName)
+	switch DBDriver {
+	case "mysql":
+		DB, err = openMySQL()
+	case "sqlite":
+		DB, err = openSQLite()
+	default:
+		log.Fatalf("不支持的数据库驱动: %s", DBDriver)
+	}
 	if err != nil {
 		log.Fatal("数据库连接失败: ", err)
 	}
@@ -36,6 +40,38 @@ func ConnectDB() {
 	fmt.Println("数据库连接成功!")
 }
 
+// openMySQL 使用环境变量中的配置打开 MySQL 连接
+func openMySQL() (*sql.DB, error) {
+	// 从环境变量获取数据库配置
+	dbUser := getEnv("DB_USER", "root")
+	dbPass := getEnv("DB_PASSWORD", "rootpassword")
+	dbHost := getEnv("DB_HOST", "localhost")
+	dbPort := getEnv("DB_PORT", "3306")
+	dbName := getEnv("DB_NAME", "starpool")
+
+	// 创建数据库连接字符串
+	// clientFoundRows 使影响行数按匹配的行计算，值未变化的更新不会被误判为记录不存在
+	// 会话时区固定为 UTC，与驱动解析时间使用的 loc=UTC 一致，TIMESTAMP 的读写不受服务器 time_zone 设置影响
+	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&charset=utf8mb4&collation=utf8mb4_unicode_ci&clientFoundRows=true&loc=UTC&time_zone=%%27%%2B00%%3A00%%27", dbUser, dbPass, dbHost, dbPort, dbName)
+
+	return sql.Open("mysql", dataSourceName)
+}
+
+// openSQLite 打开 DB_PATH 指定的 SQLite 数据库文件
+func openSQLite() (*sql.DB, error) {
+	dbPath := getEnv("DB_PATH", "starpool.db")
+
+	// 开启外键约束以支持级联删除，WAL 模式和忙等待用于减少并发写入时的锁冲突
+	// 事务以 BEGIN IMMEDIATE 开始，写事务在开始时即获取写锁，避免读后升级写锁时出现死锁
+	dataSourceName := fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate", dbPath)
+	return sql.Open("sqlite3", dataSourceName)
+}
+
+// AutoMigrate 返回启动时是否自动应用待执行的迁移，由 DB_AUTO_MIGRATE 控制，默认开启
+func AutoMigrate() bool {
+	return getEnv("DB_AUTO_MIGRATE", "true") == "true"
+}
+
 // getEnv 获取环境变量，如果不存在则返回默认值
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
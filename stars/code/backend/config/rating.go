@@ -0,0 +1,28 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// StreakMinRating 返回计入连续评分天数的最低评分，由 STREAK_MIN_RATING 指定（1-5），默认1即任何评分都计入
+func StreakMinRating() int {
+	value := getEnv("STREAK_MIN_RATING", "1")
+	rating, err := strconv.Atoi(value)
+	if err != nil || rating < 1 || rating > 5 {
+		log.Fatalf("无效的 STREAK_MIN_RATING: %s", value)
+	}
+	return rating
+}
+
+// Timezone 返回换算评分日期和"今天"使用的默认时区，由 APP_TIMEZONE 指定 IANA 时区名（如 Asia/Shanghai），默认 UTC
+// 用户设置了自己的时区时以用户的时区为准
+func Timezone() *time.Location {
+	name := getEnv("APP_TIMEZONE", "UTC")
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		log.Fatalf("无效的 APP_TIMEZONE: %s", name)
+	}
+	return loc
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"starpool/achievements"
+	"starpool/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AchievementController 处理成就徽章相关的HTTP请求
+type AchievementController struct {
+	Achievements *achievements.Engine // 成就评估
+	Clock        *Clock               // 按用户时区计算今天
+}
+
+// GetAchievements 获取成就徽章
+// @Summary 获取成就徽章
+// @Description 返回当前用户已获得的徽章（附获得时间）和尚未获得的徽章（附当前进度 progress 和所需进度 target），按规则顺序排列；
+// @Description 连续评分类徽章的进度按进行中的目标截至今天（按用户时区）的评分计算
+// @Tags achievements
+// @Produce json
+// @Success 200 {object} map[string][]achievements.Badge
+// @Router /achievements [get]
+func (ac *AchievementController) GetAchievements(c *gin.Context) {
+	badges, err := ac.Achievements.Badges(c.Request.Context(), auth.CurrentUser(c).ID, ac.Clock.Today(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	earned, locked := []achievements.Badge{}, []achievements.Badge{}
+	for _, badge := range badges {
+		if badge.Earned {
+			earned = append(earned, badge)
+		} else {
+			locked = append(locked, badge)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"earned": earned, "locked": locked})
+}
+
+// evaluateAchievements 在创建目标或评分成功后按触发事件评估成就，返回新获得的徽章
+// 评估失败不影响已经成功的请求，只记录日志
+func evaluateAchievements(c *gin.Context, engine *achievements.Engine, event achievements.Event) []achievements.Badge {
+	if engine == nil {
+		return []achievements.Badge{}
+	}
+	badges, err := engine.Evaluate(c.Request.Context(), auth.CurrentUser(c).ID, event)
+	if err != nil {
+		log.Printf("评估成就失败: %v", err)
+		return []achievements.Badge{}
+	}
+	return badges
+}
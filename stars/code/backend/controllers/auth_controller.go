@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthController 处理用户注册、登录相关的HTTP请求
+type AuthController struct {
+	Users  store.UserStore    // 用户存储
+	Tokens *auth.TokenManager // 访问令牌签发
+}
+
+// Credentials 注册和登录的请求体
+type Credentials struct {
+	Username string `json:"username"` // 用户名
+	Password string `json:"password"` // 密码
+}
+
+// UserSettingsRequest 修改当前用户设置的请求体
+type UserSettingsRequest struct {
+	Timezone          *string `json:"timezone"`            // IANA 时区名（如 Asia/Shanghai），为空字符串表示使用默认时区，不提供时保持不变
+	LeaderboardOptOut *bool   `json:"leaderboard_opt_out"` // 是否不参加排行榜，不提供时保持不变
+}
+
+// TokenResponse 注册和登录成功后返回的访问令牌
+type TokenResponse struct {
+	User        *models.User `json:"user"`         // 当前用户
+	AccessToken string       `json:"access_token"` // 访问令牌，放在 Authorization: Bearer 请求头中
+	ExpiresAt   time.Time    `json:"expires_at"`   // 令牌过期时间
+}
+
+// Register 注册新用户
+// @Summary 注册新用户
+// @Description 使用用户名和密码注册账号，成功后直接返回访问令牌
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body Credentials true "用户名和密码"
+// @Success 201 {object} TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/register [post]
+func (ac *AuthController) Register(c *gin.Context) {
+	// 解析请求体
+	var credentials Credentials
+	if err := c.ShouldBindJSON(&credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 验证用户名和密码
+	username := strings.TrimSpace(credentials.Username)
+	if length := utf8.RuneCountInString(username); length < 2 || length > 32 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户名长度必须在2-32个字符之间"})
+		return
+	}
+	if len(credentials.Password) < 8 || len(credentials.Password) > 72 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "密码长度必须在8-72个字节之间"})
+		return
+	}
+
+	// 计算密码哈希
+	hash, err := auth.HashPassword(credentials.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 保存用户
+	user := models.User{Username: username, PasswordHash: hash}
+	if err := ac.Users.CreateUser(c.Request.Context(), &user); err != nil {
+		if errors.Is(err, store.ErrDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "用户名已被注册"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ac.respondToken(c, http.StatusCreated, &user)
+}
+
+// Login 用户登录
+// @Summary 用户登录
+// @Description 校验用户名和密码，成功后返回访问令牌
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body Credentials true "用户名和密码"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/login [post]
+func (ac *AuthController) Login(c *gin.Context) {
+	// 解析请求体
+	var credentials Credentials
+	if err := c.ShouldBindJSON(&credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 查询用户并校验密码，用户不存在和密码错误返回相同的提示
+	user, err := ac.Users.GetUserByUsername(c.Request.Context(), strings.TrimSpace(credentials.Username))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if user == nil || !auth.CheckPassword(user.PasswordHash, credentials.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+		return
+	}
+
+	ac.respondToken(c, http.StatusOK, user)
+}
+
+// GetCurrentUser 获取当前登录的用户
+// @Summary 获取当前登录的用户
+// @Description 返回访问令牌对应的用户信息
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 401 {object} map[string]string
+// @Router /auth/me [get]
+func (ac *AuthController) GetCurrentUser(c *gin.Context) {
+	user, err := ac.Users.GetUser(c.Request.Context(), auth.CurrentUser(c).ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateCurrentUser 修改当前用户的设置
+// @Summary 修改当前用户的设置
+// @Description 设置当前用户的时区（IANA 时区名），评分日期和"今天"按该时区计算，为空表示使用服务配置的默认时区；
+// @Description 设置是否不参加排行榜。未提供的设置保持不变
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param settings body UserSettingsRequest true "用户设置"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/me [put]
+func (ac *AuthController) UpdateCurrentUser(c *gin.Context) {
+	// 解析请求体
+	var request UserSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 读取当前设置
+	user, err := ac.Users.GetUser(c.Request.Context(), auth.CurrentUser(c).ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 验证时区
+	if request.Timezone != nil {
+		timezone := strings.TrimSpace(*request.Timezone)
+		if timezone != "" {
+			if _, err := LoadTimezone(timezone); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区: " + timezone})
+				return
+			}
+		}
+		user.Timezone = timezone
+	}
+	if request.LeaderboardOptOut != nil {
+		user.LeaderboardOptOut = *request.LeaderboardOptOut
+	}
+
+	// 保存设置
+	if err := ac.Users.UpdateUserSettings(c.Request.Context(), user); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ac.GetCurrentUser(c)
+}
+
+// respondToken 为用户签发访问令牌并返回
+func (ac *AuthController) respondToken(c *gin.Context, status int, user *models.User) {
+	token, expiresAt, err := ac.Tokens.Issue(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, TokenResponse{User: user, AccessToken: token, ExpiresAt: expiresAt})
+}
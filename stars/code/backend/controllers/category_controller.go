@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// categoryActivityDays 类别列表返回最近多少天的评分情况
+const categoryActivityDays = 7
+
+// 类别名和图标的最大长度
+const (
+	maxCategoryNameLength = 50
+	maxCategoryIconLength = 50
+)
+
+// categoryColorPattern 类别颜色的格式
+var categoryColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// CategoryController 处理类别相关的HTTP请求
+type CategoryController struct {
+	Categories store.CategoryStore // 类别存储
+	Clock      *Clock              // 按用户时区计算今天
+}
+
+// CategoryRequest 创建或更新类别的请求
+type CategoryRequest struct {
+	Name             string `json:"name" binding:"required"` // 类别名
+	Color            string `json:"color"`                   // 显示颜色，#RRGGBB 格式
+	Icon             string `json:"icon"`                    // 图标名或 emoji
+	SortOrder        int    `json:"sort_order"`              // 排序值，越小越靠前
+	WeeklyStarBudget *int   `json:"weekly_star_budget"`      // 每周星数预算，为空表示不设预算
+}
+
+// GetCategories 获取所有类别及统计
+// @Summary 获取所有类别及统计
+// @Description 按排序值获取当前用户的所有类别，附带目标数 goal_count、星数合计 total_stars、
+// @Description 最近7天每天的评分次数和星数 activity（没有评分的日期记为0）及其合计 week_stars
+// @Tags categories
+// @Produce json
+// @Success 200 {array} models.Category
+// @Router /categories [get]
+func (cc *CategoryController) GetCategories(c *gin.Context) {
+	from := models.Date{Time: cc.Clock.Today(c).AddDate(0, 0, 1-categoryActivityDays)}
+
+	categories, err := cc.Categories.ListCategories(c.Request.Context(), auth.CurrentUser(c).ID, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range categories {
+		categories[i].FillActivity(from, categoryActivityDays)
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory 创建新类别
+// @Summary 创建新类别
+// @Description 创建一个新类别，可以设置颜色 color（#RRGGBB）、图标 icon、排序值 sort_order 和每周星数预算 weekly_star_budget
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body CategoryRequest true "类别信息"
+// @Success 201 {object} models.Category
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /categories [post]
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	// 解析请求体
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category, err := request.category()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 保存类别，所属用户为当前用户
+	category.OwnerID = auth.CurrentUser(c).ID
+	if err := cc.Categories.CreateCategory(c.Request.Context(), category); err != nil {
+		if errors.Is(err, store.ErrDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "已存在同名类别"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 返回创建的类别
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory 更新类别
+// @Summary 更新类别
+// @Description 更新类别的名称、颜色、图标、排序值和每周星数预算，改名时该类别下的目标随之更新
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "类别ID"
+// @Param category body CategoryRequest true "更新的类别信息"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /categories/{id} [put]
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的类别ID"})
+		return
+	}
+
+	// 解析请求体
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category, err := request.category()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 检查类别是否存在且属于当前用户
+	if _, ok := cc.authorizeCategory(c, id); !ok {
+		return
+	}
+
+	// 更新类别
+	category.ID = id
+	if err := cc.Categories.UpdateCategory(c.Request.Context(), category); err != nil {
+		if errors.Is(err, store.ErrDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "已存在同名类别"})
+			return
+		}
+		respondStoreError(c, err, "类别未找到")
+		return
+	}
+
+	// 返回更新后的类别
+	updated, err := cc.Categories.GetCategory(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "类别未找到")
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCategory 删除类别
+// @Summary 删除类别
+// @Description 删除类别，该类别下目标的类别被清空，目标本身不受影响
+// @Tags categories
+// @Param id path int true "类别ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /categories/{id} [delete]
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的类别ID"})
+		return
+	}
+
+	// 检查类别是否存在且属于当前用户
+	if _, ok := cc.authorizeCategory(c, id); !ok {
+		return
+	}
+
+	// 删除类别
+	if err := cc.Categories.DeleteCategory(c.Request.Context(), id); err != nil {
+		respondStoreError(c, err, "类别未找到")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authorizeCategory 查询类别并检查当前用户是否为类别的所属用户
+// 类别不存在时返回404，不属于当前用户时返回403，此时第二个返回值为 false
+func (cc *CategoryController) authorizeCategory(c *gin.Context, id int) (*models.Category, bool) {
+	category, err := cc.Categories.GetCategory(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "类别未找到")
+		return nil, false
+	}
+	if category.OwnerID != auth.CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该类别"})
+		return nil, false
+	}
+	return category, true
+}
+
+// category 检查请求并转换为类别，类别名会被规范化
+func (r CategoryRequest) category() (*models.Category, error) {
+	name := models.NormalizeCategoryName(r.Name)
+	if err := validateCategoryName(name); err != nil {
+		return nil, err
+	}
+	if r.Color != "" && !categoryColorPattern.MatchString(r.Color) {
+		return nil, errors.New("颜色格式应为 #RRGGBB")
+	}
+	if utf8.RuneCountInString(r.Icon) > maxCategoryIconLength {
+		return nil, fmt.Errorf("图标长度不能超过%d个字符", maxCategoryIconLength)
+	}
+	if r.WeeklyStarBudget != nil && *r.WeeklyStarBudget < 1 {
+		return nil, errors.New("每周星数预算必须大于0")
+	}
+	return &models.Category{
+		Name:             name,
+		Color:            r.Color,
+		Icon:             r.Icon,
+		SortOrder:        r.SortOrder,
+		WeeklyStarBudget: r.WeeklyStarBudget,
+	}, nil
+}
+
+// validateCategoryName 检查规范化后的类别名长度是否有效
+func validateCategoryName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxCategoryNameLength {
+		return fmt.Errorf("类别名长度必须在1到%d个字符之间", maxCategoryNameLength)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// locationKey 当前请求用户时区在 gin.Context 中的键
+const locationKey = "starpool.location"
+
+// Clock 按当前用户的时区计算日历日期，用户未设置时区时使用默认时区
+type Clock struct {
+	Users   store.UserStore // 用户存储，用于读取用户的时区设置
+	Default *time.Location  // 默认时区
+}
+
+// Location 返回当前用户的时区，同一请求内只查询一次
+// 用户的时区设置无效或无法读取时使用默认时区
+func (k *Clock) Location(c *gin.Context) *time.Location {
+	if value, ok := c.Get(locationKey); ok {
+		return value.(*time.Location)
+	}
+	loc := k.Default
+	if loc == nil {
+		loc = time.UTC
+	}
+	if user, err := k.Users.GetUser(c.Request.Context(), auth.CurrentUser(c).ID); err == nil && user.Timezone != "" {
+		if userLoc, err := LoadTimezone(user.Timezone); err == nil {
+			loc = userLoc
+		}
+	}
+	c.Set(locationKey, loc)
+	return loc
+}
+
+// Today 返回当前用户时区的今天
+func (k *Clock) Today(c *gin.Context) models.Date {
+	return models.DateOf(time.Now().In(k.Location(c)))
+}
+
+// ParseDay 解析评分日期：YYYY-MM-DD 直接作为日历日期，RFC3339 时间换算为当前用户时区的日期，为空时为今天
+func (k *Clock) ParseDay(c *gin.Context, value string) (models.Date, error) {
+	if value == "" {
+		return k.Today(c), nil
+	}
+	if len(value) == len(models.DateLayout) {
+		return models.ParseDate(value)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return models.Date{}, fmt.Errorf("日期格式应为 YYYY-MM-DD 或 RFC3339: %s", value)
+	}
+	return models.DateOf(t.In(k.Location(c))), nil
+}
+
+// LoadTimezone 加载 IANA 时区名，拒绝依赖服务器环境的 Local
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, errors.New("不支持 Local 时区")
+	}
+	return time.LoadLocation(name)
+}
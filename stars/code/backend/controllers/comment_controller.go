@@ -1,18 +1,25 @@
 package controllers
 
 import (
-	"database/sql"
 	"net/http"
-	"starpool/config"
 	"starpool/models"
+	"starpool/store"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CommentController 处理评论相关的HTTP请求
-type CommentController struct{}
+type CommentController struct {
+	Goals    store.GoalStore    // 目标存储
+	Comments store.CommentStore // 评论存储
+}
+
+// CommentRequest 修改评论的请求
+type CommentRequest struct {
+	Content string `json:"content" binding:"required"` // 新的评论内容
+}
 
 // CreateComment 创建新评论
 // @Summary 创建新评论
@@ -25,6 +32,7 @@ type CommentController struct{}
 // @Success 201 {object} models.Comment
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /goals/{id}/comments [post]
 func (cc *CommentController) CreateComment(c *gin.Context) {
 	// 获取路径参数
@@ -41,65 +49,185 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 检查目标是否存在
-	var goal models.StarGoal
-	query := `SELECT id FROM star_goals WHERE id = ?`
-	err = config.DB.QueryRow(query, goalId).Scan(&goal.ID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, cc.Goals, goalId); !ok {
 		return
 	}
 
-	// 如果提供了父评论ID，检查父评论是否存在
+	// 如果提供了父评论ID，检查父评论是否存在且未被删除
 	if comment.ParentID != nil {
-		var parentComment models.Comment
-		query = `SELECT id FROM comments WHERE id = ? AND goal_id = ?`
-		err = config.DB.QueryRow(query, *comment.ParentID, goalId).Scan(&parentComment.ID)
+		parent, err := cc.Comments.GetComment(c.Request.Context(), goalId, *comment.ParentID)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "父评论未找到或不属于该目标"})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			}
+			respondStoreError(c, err, "父评论未找到或不属于该目标")
+			return
+		}
+		if parent.DeletedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不能回复已删除的评论"})
 			return
 		}
 	}
 
-	// 插入数据库
-	query = `INSERT INTO comments(goal_id, parent_id, content, created_at) VALUES (?, ?, ?, NOW())`
-	result, err := config.DB.Exec(query, goalId, comment.ParentID, comment.Content)
+	// 保存评论
+	comment.GoalID = goalId
+	if err := cc.Comments.CreateComment(c.Request.Context(), &comment); err != nil {
+		respondStoreError(c, err, "目标未找到")
+		return
+	}
+
+	// 返回创建的评论
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment 修改评论
+// @Summary 修改评论
+// @Description 修改指定目标下的评论内容，修改前的内容保存到编辑历史，edited_at 为最后一次修改的时间；已删除的评论不能修改
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param commentId path int true "评论ID"
+// @Param comment body CommentRequest true "新的评论内容"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /goals/{id}/comments/{commentId} [put]
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	// 获取路径参数
+	goalId, commentId, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	// 解析请求体
+	var request CommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(request.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评论内容不能为空"})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, cc.Goals, goalId); !ok {
+		return
+	}
+
+	// 修改评论
+	comment := models.Comment{ID: commentId, GoalID: goalId, Content: request.Content}
+	if err := cc.Comments.UpdateComment(c.Request.Context(), &comment); err != nil {
+		respondStoreError(c, err, "评论未找到或不属于该目标")
+		return
+	}
+
+	// 返回修改后的评论
+	updated, err := cc.Comments.GetComment(c.Request.Context(), goalId, commentId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err, "评论未找到或不属于该目标")
 		return
 	}
+	c.JSON(http.StatusOK, updated)
+}
 
-	// 获取插入记录的ID
-	id, err := result.LastInsertId()
+// DeleteComment 删除评论
+// @Summary 删除评论
+// @Description 删除指定目标下的评论。评论有回复时清空内容和编辑历史，保留为已删除的占位，回复仍挂在原处；
+// @Description 没有回复时直接删除，因此不再有回复的已删除上级评论一并删除
+// @Tags comments
+// @Param id path int true "目标ID"
+// @Param commentId path int true "评论ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /goals/{id}/comments/{commentId} [delete]
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	// 获取路径参数
+	goalId, commentId, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, cc.Goals, goalId); !ok {
+		return
+	}
+
+	// 删除评论
+	if _, err := cc.Comments.DeleteComment(c.Request.Context(), goalId, commentId); err != nil {
+		respondStoreError(c, err, "评论未找到或不属于该目标")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCommentHistory 获取评论的编辑历史
+// @Summary 获取评论的编辑历史
+// @Description 按编辑时间顺序获取评论每次修改前的内容
+// @Tags comments
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param commentId path int true "评论ID"
+// @Success 200 {array} models.CommentEdit
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /goals/{id}/comments/{commentId}/history [get]
+func (cc *CommentController) GetCommentHistory(c *gin.Context) {
+	// 获取路径参数
+	goalId, commentId, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户，评论是否属于该目标
+	if _, ok := authorizeGoal(c, cc.Goals, goalId); !ok {
+		return
+	}
+	if _, err := cc.Comments.GetComment(c.Request.Context(), goalId, commentId); err != nil {
+		respondStoreError(c, err, "评论未找到或不属于该目标")
+		return
+	}
+
+	// 查询编辑历史
+	edits, err := cc.Comments.ListCommentEdits(c.Request.Context(), commentId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, edits)
+}
 
-	comment.ID = int(id)
-	comment.GoalID = goalId
-	comment.CreatedAt = time.Now()
-
-	// 返回创建的评论
-	c.JSON(http.StatusCreated, comment)
+// commentParams 解析路径中的目标ID和评论ID，无效时返回400，此时第三个返回值为 false
+func commentParams(c *gin.Context) (int, int, bool) {
+	goalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return 0, 0, false
+	}
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的评论ID"})
+		return 0, 0, false
+	}
+	return goalId, commentId, true
 }
 
 // GetCommentsByGoalID 获取指定目标的所有评论（嵌套结构）
 // @Summary 获取指定目标的所有评论
-// @Description 获取指定目标的所有评论，包括回复，并以嵌套结构返回
+// @Description 获取指定目标的所有评论，包括回复，并以嵌套结构返回；
+// @Description 已删除但有回复的评论以占位返回（deleted 为 true，内容为空），不计入总评论数
 // @Tags comments
 // @Produce json
 // @Param id path int true "目标ID"
 // @Success 200 {array} models.Comment
 // @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /goals/{id}/comments [get]
 func (cc *CommentController) GetCommentsByGoalID(c *gin.Context) {
     // 获取路径参数
@@ -109,45 +237,17 @@ func (cc *CommentController) GetCommentsByGoalID(c *gin.Context) {
         return
     }
 
-    // 检查目标是否存在
-    var goal models.StarGoal
-    query := `SELECT id FROM star_goals WHERE id = ?`
-    err = config.DB.QueryRow(query, goalId).Scan(&goal.ID)
-    if err != nil {
-        if err == sql.ErrNoRows {
-            c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
-        } else {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        }
+    // 检查目标是否存在且属于当前用户
+    if _, ok := authorizeGoal(c, cc.Goals, goalId); !ok {
         return
     }
 
     // 查询评论
-    query = `SELECT id, goal_id, parent_id, content, created_at FROM comments WHERE goal_id = ? ORDER BY created_at ASC`
-    rows, err := config.DB.Query(query, goalId)
+    comments, err := cc.Comments.ListComments(c.Request.Context(), goalId)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    defer rows.Close()
-
-    // 遍历结果
-    var comments []models.Comment
-    for rows.Next() {
-        var comment models.Comment
-        err := rows.Scan(&comment.ID, &comment.GoalID, &comment.ParentID, &comment.Content, &comment.CreatedAt)
-        if err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-            return
-        }
-        comments = append(comments, comment)
-    }
-
-    // 检查遍历过程中是否有错误
-    if err = rows.Err(); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
 
     // 构建嵌套评论结构
     nestedComments := buildNestedComments(comments)
@@ -162,9 +262,15 @@ func (cc *CommentController) GetCommentsByGoalID(c *gin.Context) {
     })
 }
 
-// countTotalComments 计算评论总数（包括回复）
+// countTotalComments 计算评论总数（包括回复，不包括已删除评论的占位）
 func countTotalComments(comments []models.Comment) int {
-    return len(comments)
+    total := 0
+    for _, comment := range comments {
+        if comment.DeletedAt == nil {
+            total++
+        }
+    }
+    return total
 }
 
 // buildNestedComments 构建嵌套评论结构
@@ -181,6 +287,9 @@ func buildNestedComments(comments []models.Comment) []map[string]interface{} {
             "parent_id":  comment.ParentID,
             "content":    comment.Content,
             "created_at": comment.CreatedAt,
+            "edited_at":  comment.EditedAt,
+            "deleted":    comment.DeletedAt != nil,
+            "deleted_at": comment.DeletedAt,
             "children":   []map[string]interface{}{},
         }
     }
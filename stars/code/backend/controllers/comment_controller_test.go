@@ -0,0 +1,43 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"starpool/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeletedCommentConflict(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.register("alice")
+	goal := ts.createGoal(token, gin.H{"title": "跑步"})
+	comments := fmt.Sprintf("/goals/%d/comments", goal.ID)
+
+	// 有回复的评论删除后保留为占位
+	var parent, reply models.Comment
+	ts.expect(http.StatusCreated, http.MethodPost, comments, token, gin.H{"content": "第一条"}, &parent)
+	ts.expect(http.StatusCreated, http.MethodPost, comments, token, gin.H{"content": "回复", "parent_id": parent.ID}, &reply)
+	path := fmt.Sprintf("%s/%d", comments, parent.ID)
+	ts.expect(http.StatusNoContent, http.MethodDelete, path, token, nil, nil)
+
+	var list struct {
+		Comments []models.Comment `json:"comments"`
+	}
+	ts.expect(http.StatusOK, http.MethodGet, comments, token, nil, &list)
+	found := false
+	for _, comment := range list.Comments {
+		if comment.ID == parent.ID {
+			found = comment.DeletedAt != nil && comment.Content == ""
+		}
+	}
+	if !found {
+		t.Fatal("有回复的评论删除后应保留为内容为空的占位")
+	}
+
+	// 已删除的评论不能再修改或删除
+	ts.expect(http.StatusConflict, http.MethodPut, path, token, gin.H{"content": "修改"}, nil)
+	ts.expect(http.StatusConflict, http.MethodDelete, path, token, nil, nil)
+	ts.expect(http.StatusBadRequest, http.MethodPost, comments, token, gin.H{"content": "再回复", "parent_id": parent.ID}, nil)
+}
@@ -0,0 +1,148 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/routes"
+	"starpool/store"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testServer 基于内存存储和完整路由的测试服务
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	store  store.Store
+}
+
+// newTestServer 按 main.go 的方式注册路由，使用内存存储
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	s := store.NewMemoryStore()
+	tokens := auth.NewTokenManager([]byte("test-secret"), time.Hour)
+	routes.RegisterAuthRoutes(router, s, tokens)
+	routes.RegisterGoalRoutes(router, s, tokens)
+	return &testServer{t: t, router: router, store: s}
+}
+
+// do 发送请求并返回响应，body 不为空时编码为 JSON
+func (ts *testServer) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+// expect 发送请求，检查状态码并把响应解码到 out（为空时不解码）
+func (ts *testServer) expect(status int, method, path, token string, body, out interface{}) {
+	ts.t.Helper()
+	w := ts.do(method, path, token, body)
+	if w.Code != status {
+		ts.t.Fatalf("%s %s 状态码 = %d，期望 %d，响应: %s", method, path, w.Code, status, w.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			ts.t.Fatalf("%s %s 解码响应失败: %v", method, path, err)
+		}
+	}
+}
+
+// register 注册用户并返回访问令牌
+func (ts *testServer) register(username string) string {
+	ts.t.Helper()
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	body := gin.H{"username": username, "password": "password123"}
+	ts.expect(http.StatusCreated, http.MethodPost, "/auth/register", "", body, &response)
+	return response.AccessToken
+}
+
+// createGoal 创建目标并返回
+func (ts *testServer) createGoal(token string, body gin.H) models.StarGoal {
+	ts.t.Helper()
+	var goal models.StarGoal
+	ts.expect(http.StatusCreated, http.MethodPost, "/goals", token, body, &goal)
+	return goal
+}
+
+// rate 为目标在指定日期（YYYY-MM-DD）评分
+func (ts *testServer) rate(token string, goalID, rating int, date string) {
+	ts.t.Helper()
+	ts.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/goals/%d/daily-rating", goalID), token, gin.H{"rating": rating, "date": date}, nil)
+}
+
+// stars 返回当前用户的总星数
+func (ts *testServer) stars(token string) int {
+	ts.t.Helper()
+	var response struct {
+		TotalStars int `json:"total_stars"`
+	}
+	ts.expect(http.StatusOK, http.MethodGet, "/stars", token, nil, &response)
+	return response.TotalStars
+}
+
+// day 返回距今天 offset 天的日期（默认时区 UTC）
+func day(offset int) string {
+	return time.Now().UTC().AddDate(0, 0, offset).Format(models.DateLayout)
+}
+
+func TestControllersUseInjectedStore(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	token := ts.register("alice")
+
+	// 通过接口创建的目标和评论写入注入的存储
+	goal := ts.createGoal(token, gin.H{"title": "跑步"})
+	stored, err := ts.store.GetGoal(ctx, goal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "跑步" {
+		t.Fatalf("存储中的目标标题 = %q，期望 跑步", stored.Title)
+	}
+	var comment models.Comment
+	ts.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/goals/%d/comments", goal.ID), token, gin.H{"content": "加油"}, &comment)
+	comments, err := ts.store.ListComments(ctx, goal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].ID != comment.ID {
+		t.Fatalf("存储中的评论 = %+v，期望只有评论 %d", comments, comment.ID)
+	}
+
+	// 直接写入存储的数据通过接口可见，存储返回的 ErrNotFound 映射为404
+	stored.Title = "慢跑"
+	if err := ts.store.UpdateGoal(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	var fetched models.StarGoal
+	ts.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/goals/%d", goal.ID), token, nil, &fetched)
+	if fetched.Title != "慢跑" {
+		t.Fatalf("目标标题 = %q，期望 慢跑", fetched.Title)
+	}
+	ts.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/goals/%d", goal.ID+100), token, nil, nil)
+	ts.expect(http.StatusNotFound, http.MethodPut, fmt.Sprintf("/goals/%d/comments/%d", goal.ID, comment.ID+100), token, gin.H{"content": "修改"}, nil)
+}
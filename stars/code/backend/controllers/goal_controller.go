@@ -1,21 +1,110 @@
 package controllers
 
 import (
-	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
-	"starpool/config"
+	"sort"
+	"starpool/achievements"
+	"starpool/auth"
 	"starpool/models"
+	"starpool/store"
 	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 每日评分查询的默认天数和最多返回的时间段数
+const (
+	defaultRatingDays = 7
+	maxRatingBuckets  = 400
+)
+
+// maxRatingNoteLength 每日评分备注的最大长度
+const maxRatingNoteLength = 10000
+
+// 目标列表每页的默认条数和最大条数
+const (
+	defaultGoalsLimit = 20
+	maxGoalsLimit     = 100
+)
+
+// maxUnpaginatedGoals 逾期目标、今天需要评分的目标等不分页的列表最多查询的目标数
+const maxUnpaginatedGoals = 500
+
+// ratingStatsWeeks 目标列表、目标详情和今日待办计算连续评分天数和计划完成率时读取的评分周数，
+// 查询成本不随评分历史增长；完整的连续区间通过 GET /goals/:id/streaks 获取
+const ratingStatsWeeks = 52
+
+// GoalStatusRequest 目标状态变更请求
+type GoalStatusRequest struct {
+	Status string `json:"status" binding:"required"` // 新状态：active、paused、completed 或 archived
+}
+
+// GoalUpdateRequest 更新目标的请求，只更新请求中出现的字段，未出现的字段保持不变
+// 可以为空的字段显式传 null 时清空
+type GoalUpdateRequest struct {
+	Title       *string               `json:"title"`        // 目标标题
+	Description *string               `json:"description"`  // 目标描述
+	Category    *string               `json:"category"`     // 目标类别，空字符串表示不属于任何类别
+	Tags        *[]string             `json:"tags"`         // 标签名，替换目标原有的所有标签
+	TargetStars Nullable[int]         `json:"target_stars"` // 目标星数，null 表示不设置
+	DueDate     Nullable[models.Date] `json:"due_date"`     // 截止日期（YYYY-MM-DD），null 表示不设置
+	ParentID    Nullable[int]         `json:"parent_id"`    // 父目标ID，null 表示移动为顶层目标
+	Schedule    *models.Schedule      `json:"schedule"`     // 评分计划，替换原有的计划
+}
+
+// apply 将请求中出现的字段写入目标
+func (r *GoalUpdateRequest) apply(goal *models.StarGoal) {
+	if r.Title != nil {
+		goal.Title = *r.Title
+	}
+	if r.Description != nil {
+		goal.Description = *r.Description
+	}
+	if r.Category != nil {
+		goal.Category = *r.Category
+	}
+	if r.Tags != nil {
+		goal.Tags = *r.Tags
+	}
+	if r.TargetStars.Set {
+		goal.TargetStars = r.TargetStars.Value
+	}
+	if r.DueDate.Set {
+		goal.DueDate = r.DueDate.Value
+	}
+	if r.ParentID.Set {
+		goal.ParentID = r.ParentID.Value
+	}
+	if r.Schedule != nil {
+		goal.Schedule = *r.Schedule
+	}
+}
+
 // GoalController 处理星目标相关的HTTP请求
-type GoalController struct{}
+type GoalController struct {
+	Goals           store.GoalStore      // 目标存储
+	Ratings         store.RatingStore    // 每日评分存储
+	StreakMinRating int                  // 计入连续评分天数的默认最低评分，可通过 min_rating 参数覆盖
+	Clock           *Clock               // 按用户时区计算今天
+	Achievements    *achievements.Engine // 创建目标和评分成功后评估成就
+}
+
+// DailyRatingRequest 每日评分请求
+type DailyRatingRequest struct {
+	Rating int     `json:"rating"` // 评分 (1-5星)
+	Date   string  `json:"date"`   // 评分日期，YYYY-MM-DD 或 RFC3339（按用户时区换算为日期），为空时为今天
+	Note   *string `json:"note"`   // 日记备注（Markdown），不传时保留当天已有的备注
+	Mood   *string `json:"mood"`   // 心情：great、good、okay、bad、awful，不传时保留当天已有的心情
+}
 
 // CreateGoal 创建新目标
 // @Summary 创建新目标
-// @Description 创建一个新的星目标
+// @Description 创建一个新的星目标，可以设置目标星数 target_stars、截止日期 due_date（YYYY-MM-DD）、父目标 parent_id 和标签 tags，
+// @Description 标签名不区分大小写，不存在的类别和标签自动创建；星数只能通过评分获得，请求中的 stars 被忽略
 // @Tags goals
 // @Accept json
 // @Produce json
@@ -31,75 +120,104 @@ func (gc *GoalController) CreateGoal(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateGoal(&goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 插入数据库
-	query := `INSERT INTO star_goals(title, description, category, stars, created_at, updated_at) VALUES (?, ?, ?, ?, NOW(), NOW())`
-	result, err := config.DB.Exec(query, goal.Title, goal.Description, goal.Category, goal.Stars)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 检查父目标是否存在且属于当前用户
+	if !checkParentGoal(c, gc.Goals, goal.ParentID) {
 		return
 	}
 
-	// 获取插入记录的ID
-	id, err := result.LastInsertId()
-	if err != nil {
+	// 保存目标，所属用户为当前用户，新目标从0星开始
+	goal.OwnerID = auth.CurrentUser(c).ID
+	goal.Stars = 0
+	if err := gc.Goals.CreateGoal(c.Request.Context(), &goal); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	goal.ID = int(id)
+	// 评估成就，新获得的徽章可通过 GET /achievements 查看
+	evaluateAchievements(c, gc.Achievements, achievements.Event{GoalID: goal.ID, Date: gc.Clock.Today(c)})
 
 	// 返回创建的目标
 	c.JSON(http.StatusCreated, goal)
 }
 
-// GetGoals 获取所有目标
-// @Summary 获取所有目标
-// @Description 获取所有已创建的星目标
+// GetGoals 分页获取目标
+// @Summary 分页获取目标
+// @Description 按过滤条件和排序方式分页获取当前用户的星目标，使用上一页返回的 next_cursor 获取下一页
 // @Tags goals
 // @Produce json
-// @Success 200 {array} models.StarGoal
+// @Param limit query int false "每页条数，默认20，最大100"
+// @Param cursor query string false "分页游标"
+// @Param sort query string false "排序字段：stars、created_at（默认）、updated_at、title"
+// @Param order query string false "排序方向：asc 或 desc（默认）"
+// @Param category query string false "目标类别"
+// @Param status query []string false "目标状态，可重复指定，默认返回除 archived 外的所有目标"
+// @Param tag query []string false "标签名，可重复指定"
+// @Param tag_mode query string false "标签匹配方式：any（默认，包含任意一个）或 all（包含全部）"
+// @Param min_rating query int false "计入连续评分天数的最低评分（1-5），默认由服务配置决定"
+// @Param min_stars query int false "最少星数"
+// @Param max_stars query int false "最多星数"
+// @Param created_from query string false "创建日期下限，YYYY-MM-DD（按用户时区）或 RFC3339"
+// @Param created_to query string false "创建日期上限，YYYY-MM-DD（按用户时区，含当天）或 RFC3339"
+// @Success 200 {object} store.GoalPage
+// @Failure 400 {object} map[string]string
 // @Router /goals [get]
 func (gc *GoalController) GetGoals(c *gin.Context) {
-	// 查询数据库
-	query := `SELECT id, title, description, category, stars, created_at, updated_at FROM star_goals`
-	rows, err := config.DB.Query(query)
+	// 解析查询参数
+	query, err := parseGoalQuery(c, gc.Clock.Location(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	// 遍历结果
-	var goals []models.StarGoal
-	for rows.Next() {
-		var goal models.StarGoal
-		err := rows.Scan(&goal.ID, &goal.Title, &goal.Description, &goal.Category, &goal.Stars, &goal.CreatedAt, &goal.UpdatedAt)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		goals = append(goals, goal)
-	}
+	gc.listGoals(c, query)
+}
 
-	// 检查遍历过程中是否有错误
-	if err = rows.Err(); err != nil {
+// GetOverdueGoals 获取逾期目标
+// @Summary 获取逾期目标
+// @Description 获取当前用户已过截止日期、尚未达到目标星数且未完成或归档的目标，按截止日期升序排列；
+// @Description 不分页，最多返回创建最早的500个目标，超出时 truncated 为 true
+// @Tags goals
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /goals/overdue [get]
+func (gc *GoalController) GetOverdueGoals(c *gin.Context) {
+	// 查询逾期目标
+	today := gc.Clock.Today(c)
+	page, err := gc.Goals.ListGoals(c.Request.Context(), store.GoalQuery{
+		OwnerID:     auth.CurrentUser(c).ID,
+		Statuses:    []string{models.GoalStatusActive, models.GoalStatusPaused},
+		DueBefore:   &today,
+		BelowTarget: true,
+		Sort:        store.GoalSortCreatedAt,
+		Limit:       maxUnpaginatedGoals,
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 返回所有目标
-	c.JSON(http.StatusOK, goals)
+	// 按截止日期升序返回
+	goals := page.Goals
+	sort.SliceStable(goals, func(i, j int) bool { return goals[i].DueDate.Before(goals[j].DueDate.Time) })
+	c.JSON(http.StatusOK, gin.H{"goals": goals, "total": len(goals), "truncated": page.NextCursor != ""})
 }
 
 // GetGoalByID 根据ID获取单个目标
 // @Summary 根据ID获取单个目标
-// @Description 根据ID获取特定的星目标
+// @Description 根据ID获取特定的星目标，附带子目标树 children、自身及所有子孙目标的星数合计 rollup_stars，
+// @Description 以及当前和最长连续评分天数 current_streak、longest_streak
 // @Tags goals
 // @Produce json
 // @Param id path int true "目标ID"
+// @Param min_rating query int false "计入连续评分天数的最低评分（1-5），默认由服务配置决定"
 // @Success 200 {object} models.StarGoal
 // @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /goals/{id} [get]
 func (gc *GoalController) GetGoalByID(c *gin.Context) {
 	// 获取路径参数
@@ -109,36 +227,48 @@ func (gc *GoalController) GetGoalByID(c *gin.Context) {
 		return
 	}
 
-	// 查询数据库
-	var goal models.StarGoal
-	query := `SELECT id, title, description, category, stars, created_at, updated_at FROM star_goals WHERE id = ?`
-	err = config.DB.QueryRow(query, id).Scan(&goal.ID, &goal.Title, &goal.Description, &goal.Category, &goal.Stars, &goal.CreatedAt, &goal.UpdatedAt)
+	// 查询目标并检查是否属于当前用户
+	goal, ok := authorizeGoal(c, gc.Goals, id)
+	if !ok {
+		return
+	}
 
-	// 处理查询结果
+	minRating, err := gc.minRating(c)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 查询子孙目标，计算连续评分天数后组装子目标树并汇总星数
+	descendants, err := gc.Goals.ListGoalDescendants(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	goals := append([]models.StarGoal{*goal}, descendants...)
+	if err := gc.attachRatingStats(c, goals, minRating, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 返回目标
-	c.JSON(http.StatusOK, goal)
+	c.JSON(http.StatusOK, models.BuildGoalTree(goals[0], goals[1:]))
 }
 
 // UpdateGoal 更新目标
 // @Summary 更新目标
-// @Description 更新特定星目标的信息
+// @Description 更新特定星目标的标题、描述、类别、标签、目标星数、截止日期、评分计划和父目标，只更新请求中出现的字段，
+// @Description target_stars、due_date、parent_id 传 null 时清空；星数需通过星数流水调整，调低目标星数使其已经达到时目标自动完成；
+// @Description 父目标不能是目标自身或其子孙目标
 // @Tags goals
 // @Accept json
 // @Produce json
 // @Param id path int true "目标ID"
-// @Param goal body models.StarGoal true "更新的目标信息"
+// @Param goal body GoalUpdateRequest true "需要更新的字段"
 // @Success 200 {object} models.StarGoal
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /goals/{id} [put]
 func (gc *GoalController) UpdateGoal(c *gin.Context) {
 	// 获取路径参数
@@ -149,45 +279,118 @@ func (gc *GoalController) UpdateGoal(c *gin.Context) {
 	}
 
 	// 解析请求体
-	var goal models.StarGoal
-	if err := c.ShouldBindJSON(&goal); err != nil {
+	var request GoalUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户，在原有目标上应用请求中的字段
+	existing, ok := authorizeGoal(c, gc.Goals, id)
+	if !ok {
+		return
+	}
+	goal := *existing
+	request.apply(&goal)
+
+	if err := validateGoal(&goal); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if request.ParentID.Set && !checkParentGoal(c, gc.Goals, goal.ParentID) {
+		return
+	}
 
-	// 更新数据库
-	query := `UPDATE star_goals SET title = ?, description = ?, category = ?, stars = ?, updated_at = NOW() WHERE id = ?`
-	result, err := config.DB.Exec(query, goal.Title, goal.Description, goal.Category, goal.Stars, id)
+	// 更新目标
+	if err := gc.Goals.UpdateGoal(c.Request.Context(), &goal); err != nil {
+		if errors.Is(err, store.ErrGoalCycle) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondStoreError(c, err, "目标未找到")
+		return
+	}
+
+	// 返回更新后的目标
+	updated, err := gc.Goals.GetGoal(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err, "目标未找到")
 		return
 	}
+	c.JSON(http.StatusOK, updated)
+}
 
-	// 检查是否有记录被更新
-	rowsAffected, err := result.RowsAffected()
+// UpdateGoalStatus 变更目标状态
+// @Summary 变更目标状态
+// @Description 将目标变更为 active、paused、completed 或 archived，只允许合法的状态变更：
+// @Description 进行中可暂停、完成或归档；暂停可恢复、完成或归档；已完成可重新开始或归档；已归档可恢复为进行中或已完成
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param status body GoalStatusRequest true "新状态"
+// @Success 200 {object} models.StarGoal
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /goals/{id}/status [put]
+func (gc *GoalController) UpdateGoalStatus(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
 		return
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
+	// 解析请求体
+	var request GoalStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.ValidGoalStatus(request.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的目标状态: %s", request.Status)})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	goal, ok := authorizeGoal(c, gc.Goals, id)
+	if !ok {
+		return
+	}
+
+	// 检查状态变更是否合法
+	if !models.CanTransition(goal.Status, request.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("目标不能从 %s 变更为 %s", goal.Status, request.Status)})
+		return
+	}
+	if err := gc.Goals.UpdateGoalStatus(c.Request.Context(), id, goal.Status, request.Status); err != nil {
+		respondStoreError(c, err, "目标未找到")
 		return
 	}
 
 	// 返回更新后的目标
-	goal.ID = id
-	c.JSON(http.StatusOK, goal)
+	updated, err := gc.Goals.GetGoal(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "目标未找到")
+		return
+	}
+	c.JSON(http.StatusOK, updated)
 }
 
 // DeleteGoal 删除目标
 // @Summary 删除目标
-// @Description 删除特定的星目标
+// @Description 删除特定的星目标，children=cascade 时一并删除所有子孙目标，
+// @Description children=reparent（默认）时子目标转移到被删除目标的父目标下；
+// @Description 被删除目标的星数流水保留（不再关联目标），星数余额不变
 // @Tags goals
 // @Produce json
 // @Param id path int true "目标ID"
+// @Param children query string false "子目标的处理方式：reparent（默认）或 cascade"
 // @Success 204 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /goals/{id} [delete]
 func (gc *GoalController) DeleteGoal(c *gin.Context) {
 	// 获取路径参数
@@ -197,23 +400,25 @@ func (gc *GoalController) DeleteGoal(c *gin.Context) {
 		return
 	}
 
-	// 删除数据库记录
-	query := `DELETE FROM star_goals WHERE id = ?`
-	result, err := config.DB.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 获取子目标的处理方式
+	var cascade bool
+	switch c.DefaultQuery("children", "reparent") {
+	case "reparent":
+	case "cascade":
+		cascade = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "children 只能是 reparent 或 cascade"})
 		return
 	}
 
-	// 检查是否有记录被删除
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, gc.Goals, id); !ok {
 		return
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
+	// 删除目标
+	if err := gc.Goals.DeleteGoal(c.Request.Context(), id, cascade); err != nil {
+		respondStoreError(c, err, "目标未找到")
 		return
 	}
 
@@ -223,59 +428,37 @@ func (gc *GoalController) DeleteGoal(c *gin.Context) {
 
 // GetGoalsByCategory 根据类别获取目标
 // @Summary 根据类别获取目标
-// @Description 根据类别获取星目标
+// @Description 根据类别分页获取当前用户的星目标，等同于 GET /goals?category={category}
 // @Tags goals
 // @Produce json
 // @Param category path string true "目标类别"
-// @Success 200 {array} models.StarGoal
+// @Success 200 {object} store.GoalPage
+// @Failure 400 {object} map[string]string
 // @Router /goals/category/{category} [get]
 func (gc *GoalController) GetGoalsByCategory(c *gin.Context) {
-	// 获取路径参数
-	category := c.Param("category")
-
-	// 查询数据库
-	query := `SELECT id, title, description, category, stars, created_at, updated_at FROM star_goals WHERE category = ?`
-	rows, err := config.DB.Query(query, category)
+	// 解析查询参数
+	query, err := parseGoalQuery(c, gc.Clock.Location(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	// 遍历结果
-	var goals []models.StarGoal
-	for rows.Next() {
-		var goal models.StarGoal
-		err := rows.Scan(&goal.ID, &goal.Title, &goal.Description, &goal.Category, &goal.Stars, &goal.CreatedAt, &goal.UpdatedAt)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		goals = append(goals, goal)
-	}
-
-	// 检查遍历过程中是否有错误
-	if err = rows.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	// 获取路径参数
+	query.Category = c.Param("category")
 
-	// 返回符合条件的目标
-	c.JSON(http.StatusOK, goals)
+	gc.listGoals(c, query)
 }
 
-// GetTotalStars 获取所有目标的总星数
-// @Summary 获取所有目标的总星数
-// @Description 获取所有星目标的星数总和
+// GetTotalStars 获取星数余额
+// @Summary 获取星数余额
+// @Description 获取当前用户由星数流水汇总得出的星数余额，兑换心愿扣除的星数已从余额中减去
 // @Tags goals
 // @Produce json
 // @Success 200 {object} map[string]int
 // @Router /stars/total [get]
 func (gc *GoalController) GetTotalStars(c *gin.Context) {
-	// 查询数据库获取总星数
-	var totalStars int
-	query := `SELECT COALESCE(SUM(stars), 0) FROM star_goals`
-	err := config.DB.QueryRow(query).Scan(&totalStars)
+	// 查询总星数
+	totalStars, err := gc.Goals.TotalStars(c.Request.Context(), auth.CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -288,15 +471,20 @@ func (gc *GoalController) GetTotalStars(c *gin.Context) {
 
 // AddDailyRating 为指定目标添加每日评分
 // @Summary 为指定目标添加每日评分
-// @Description 为指定目标添加每日评分记录
+// @Description 为指定目标添加每日评分记录，每个目标每个日历日期只保留一条评分，再次评分覆盖当天的评分；
+// @Description 日期按当前用户的时区计算，未指定时为今天，不能为将来的日期；可以附带 Markdown 备注 note 和心情 mood，
+// @Description 不传时保留当天已有的备注和心情，传空字符串则清除；只有进行中的目标可以评分，其他状态返回409；
+// @Description 调低评分后星数余额会为负（星数已被兑换心愿消费）时返回409；评分成功后评估成就，achievements 为本次新获得的徽章
 // @Tags goals
 // @Accept json
 // @Produce json
 // @Param id path int true "目标ID"
-// @Param rating body models.DailyRating true "评分信息"
-// @Success 200 {object} map[string]string
+// @Param rating body DailyRatingRequest true "评分信息"
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /goals/{id}/daily-rating [post]
 func (gc *GoalController) AddDailyRating(c *gin.Context) {
 	// 获取路径参数
@@ -307,119 +495,459 @@ func (gc *GoalController) AddDailyRating(c *gin.Context) {
 	}
 
 	// 解析请求体
-	var rating models.DailyRating
-	if err := c.ShouldBindJSON(&rating); err != nil {
+	var request DailyRatingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 验证评分值
-	if rating.Rating < 1 || rating.Rating > 5 {
+	// 验证评分值和日期
+	if request.Rating < 1 || request.Rating > 5 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "评分必须在1-5之间"})
 		return
 	}
+	date, err := gc.Clock.ParseDay(c, request.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if date.After(gc.Clock.Today(c).Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能为将来的日期评分"})
+		return
+	}
+	if request.Note != nil && utf8.RuneCountInString(*request.Note) > maxRatingNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("备注不能超过%d个字符", maxRatingNoteLength)})
+		return
+	}
+	if request.Mood != nil && !models.ValidMood(*request.Mood) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "心情只能是 great、good、okay、bad 或 awful"})
+		return
+	}
 
-	// 检查目标是否存在
-	var goal models.StarGoal
-	query := `SELECT id FROM star_goals WHERE id = ?`
-	err = config.DB.QueryRow(query, goalId).Scan(&goal.ID)
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, gc.Goals, goalId); !ok {
+		return
+	}
+
+	// 未传备注或心情时在保存评分的事务中沿用当天已有评分的值
+	rating := models.DailyRating{GoalID: goalId, Rating: request.Rating, Date: date}
+	merge := store.RatingMerge{KeepNote: request.Note == nil, KeepMood: request.Mood == nil}
+	if request.Note != nil {
+		rating.Note = *request.Note
+	}
+	if request.Mood != nil {
+		rating.Mood = *request.Mood
+	}
+
+	// 插入或更新每日评分记录，并重新计算目标的总星数
+	if err := gc.Ratings.SaveDailyRating(c.Request.Context(), &rating, merge); err != nil {
+		respondStoreError(c, err, "目标未找到")
+		return
+	}
+
+	// 评估成就并返回成功响应，附带本次新获得的徽章
+	badges := evaluateAchievements(c, gc.Achievements, achievements.Event{GoalID: rating.GoalID, Date: rating.Date})
+	c.JSON(http.StatusOK, gin.H{"message": "评分记录成功", "achievements": badges})
+}
+
+// GetDailyRatings 获取指定目标的每日评分汇总
+// @Summary 获取指定目标的每日评分汇总
+// @Description 按天、周（周一开始）或月汇总指定目标在 from 到 to（含）之间的评分，返回每个时间段的评分次数、星数合计和平均评分，
+// @Description 没有评分的时间段也会返回（count 为0，average 为空）；未指定日期时返回截至今天的最近7天；
+// @Description ratings 为范围内的每条评分，附带备注 note 和心情 mood
+// @Tags goals
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param from query string false "起始日期，YYYY-MM-DD，默认为结束日期前6天"
+// @Param to query string false "结束日期，YYYY-MM-DD，默认为当前用户时区的今天"
+// @Param granularity query string false "汇总粒度：day（默认）、week、month"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /goals/{id}/daily-ratings [get]
+func (gc *GoalController) GetDailyRatings(c *gin.Context) {
+	// 获取路径参数
+	goalId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return
+	}
+
+	// 解析查询参数
+	granularity := c.DefaultQuery("granularity", models.GranularityDay)
+	if !models.ValidGranularity(granularity) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "汇总粒度只能是 day、week 或 month"})
+		return
+	}
+	to := gc.Clock.Today(c)
+	if value := c.Query("to"); value != "" {
+		if to, err = models.ParseDate(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 to: " + err.Error()})
+			return
+		}
+	}
+	from := models.Date{Time: to.AddDate(0, 0, 1-defaultRatingDays)}
+	if value := c.Query("from"); value != "" {
+		if from, err = models.ParseDate(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 from: " + err.Error()})
+			return
 		}
+	}
+	if from.After(to.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "起始日期不能晚于结束日期"})
+		return
+	}
+	if models.CountBuckets(from, to, granularity) > maxRatingBuckets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("查询范围过大，最多返回%d个时间段", maxRatingBuckets)})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, gc.Goals, goalId); !ok {
 		return
 	}
 
-	// 插入或更新每日评分记录
-	query = `INSERT INTO daily_ratings (goal_id, rating, date, created_at) VALUES (?, ?, ?, NOW()) 
-             ON DUPLICATE KEY UPDATE rating = ?, created_at = NOW()`
-	_, err = config.DB.Exec(query, goalId, rating.Rating, rating.Date, rating.Rating)
+	// 查询每日评分记录并按粒度汇总
+	ratings, err := gc.Ratings.ListDailyRatings(c.Request.Context(), goalId, from, to)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 重新计算并更新目标的总星数
-	var totalStars int
-	query = `SELECT COALESCE(SUM(rating), 0) FROM daily_ratings WHERE goal_id = ?`
-	err = config.DB.QueryRow(query, goalId).Scan(&totalStars)
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from,
+		"to":          to,
+		"granularity": granularity,
+		"buckets":     models.BucketRatings(ratings, from, to, granularity),
+		"ratings":     ratings,
+	})
+}
+
+// GetToday 获取今天需要评分的目标
+// @Summary 获取今天需要评分的目标
+// @Description 按评分计划列出当前用户今天（按用户时区）需要评分且尚未评分的进行中目标：daily 计划每天都需要评分，
+// @Description weekdays 计划在指定的星期几需要评分，weekly 计划在本周（周一开始）评分天数不足时需要评分；
+// @Description 不分页，只检查创建最早的500个进行中目标，超出时 truncated 为 true
+// @Tags goals
+// @Produce json
+// @Param min_rating query int false "计入连续天数的最低评分（1-5），默认由服务配置 STREAK_MIN_RATING 决定"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /today [get]
+func (gc *GoalController) GetToday(c *gin.Context) {
+	minRating, err := gc.minRating(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 查询进行中的目标及其评分日期
+	page, err := gc.Goals.ListGoals(c.Request.Context(), store.GoalQuery{
+		OwnerID:  auth.CurrentUser(c).ID,
+		Statuses: []string{models.GoalStatusActive},
+		Sort:     store.GoalSortCreatedAt,
+		Limit:    maxUnpaginatedGoals,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	// 更新目标的总星数
-	query = `UPDATE star_goals SET stars = ?, updated_at = NOW() WHERE id = ?`
-	_, err = config.DB.Exec(query, totalStars, goalId)
+	ids := make([]int, len(page.Goals))
+	for i, goal := range page.Goals {
+		ids[i] = goal.ID
+	}
+	today := gc.Clock.Today(c)
+	rated, err := gc.Ratings.ListRatingDates(c.Request.Context(), ids, 1, ratingStatsFrom(today))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 返回成功响应
-	response := map[string]string{"message": "评分记录成功"}
-	c.JSON(http.StatusOK, response)
+	// 筛选今天需要评分的目标
+	goals := []models.StarGoal{}
+	for _, goal := range page.Goals {
+		if goal.Schedule.Due(rated[goal.ID], today) {
+			goals = append(goals, goal)
+		}
+	}
+	if err := gc.attachRatingStats(c, goals, minRating, rated); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"date": today, "goals": goals, "truncated": page.NextCursor != ""})
 }
 
-// GetDailyRatings 获取指定目标的所有每日评分记录
-// @Summary 获取指定目标的所有每日评分记录
-// @Description 获取指定目标的所有每日评分记录
+// GetGoalStreaks 获取目标的连续评分记录
+// @Summary 获取目标的连续评分记录
+// @Description 按目标的评分计划获取所有历史连续评分区间（每个计划日期都有不低于 min_rating 的评分，weekly 计划为连续每周达到要求的天数），
+// @Description 以及当前和最长连续天数；今天尚未评分时当前连续天数截至上一个计划日期计算
 // @Tags goals
 // @Produce json
 // @Param id path int true "目标ID"
-// @Success 200 {array} models.DailyRating
+// @Param min_rating query int false "计入连续天数的最低评分（1-5），默认由服务配置 STREAK_MIN_RATING 决定"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
-// @Router /goals/{id}/daily-ratings [get]
-func (gc *GoalController) GetDailyRatings(c *gin.Context) {
+// @Failure 403 {object} map[string]string
+// @Router /goals/{id}/streaks [get]
+func (gc *GoalController) GetGoalStreaks(c *gin.Context) {
 	// 获取路径参数
 	goalId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
 		return
 	}
-
-	// 检查目标是否存在
-	var goal models.StarGoal
-	query := `SELECT id FROM star_goals WHERE id = ?`
-	err = config.DB.QueryRow(query, goalId).Scan(&goal.ID)
+	minRating, err := gc.minRating(c)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "目标未找到"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	goal, ok := authorizeGoal(c, gc.Goals, goalId)
+	if !ok {
 		return
 	}
 
-	// 查询每日评分记录
-	query = `SELECT id, goal_id, rating, date, created_at FROM daily_ratings WHERE goal_id = ? ORDER BY date DESC limit 7 `
-	rows, err := config.DB.Query(query, goalId)
+	// 查询达标评分的日期并按计划计算连续区间
+	dates, err := gc.Ratings.ListRatingDates(c.Request.Context(), []int{goalId}, minRating, models.Date{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
+	streaks := goal.Schedule.Streaks(dates[goalId])
 
-	// 遍历结果
-	var ratings []models.DailyRating
-	for rows.Next() {
-		var rating models.DailyRating
-		err := rows.Scan(&rating.ID, &rating.GoalID, &rating.Rating, &rating.Date, &rating.CreatedAt)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	c.JSON(http.StatusOK, gin.H{
+		"min_rating": minRating,
+		"schedule":   goal.Schedule,
+		"current":    goal.Schedule.CurrentStreak(streaks, gc.Clock.Today(c)),
+		"longest":    models.LongestStreak(streaks),
+		"streaks":    streaks,
+	})
+}
+
+// minRating 解析计入连续评分天数的最低评分，未指定时使用服务配置
+func (gc *GoalController) minRating(c *gin.Context) (int, error) {
+	value := c.Query("min_rating")
+	if value == "" {
+		return max(gc.StreakMinRating, 1), nil
+	}
+	rating, err := strconv.Atoi(value)
+	if err != nil || rating < 1 || rating > 5 {
+		return 0, errors.New("min_rating 必须在1-5之间")
+	}
+	return rating, nil
+}
+
+// ratingStatsFrom 返回计算连续评分天数和计划完成率的起始日期，即 ratingStatsWeeks 周前所在周的周一
+func ratingStatsFrom(today models.Date) models.Date {
+	return models.WeekStart(models.Date{Time: today.AddDate(0, 0, -7*ratingStatsWeeks)})
+}
+
+// attachRatingStats 按目标的评分计划计算并填充当前和最长连续评分天数以及计划完成率，只统计 ratingStatsFrom 之后的评分
+// rated 为各目标在该范围内所有评分的日期，为空时查询
+func (gc *GoalController) attachRatingStats(c *gin.Context, goals []models.StarGoal, minRating int, rated map[int][]models.Date) error {
+	ids := make([]int, len(goals))
+	for i, goal := range goals {
+		ids[i] = goal.ID
+	}
+	loc, today := gc.Clock.Location(c), gc.Clock.Today(c)
+	from := ratingStatsFrom(today)
+	var err error
+	if rated == nil {
+		if rated, err = gc.Ratings.ListRatingDates(c.Request.Context(), ids, 1, from); err != nil {
+			return err
+		}
+	}
+	dates := rated
+	if minRating > 1 {
+		if dates, err = gc.Ratings.ListRatingDates(c.Request.Context(), ids, minRating, from); err != nil {
+			return err
 		}
-		ratings = append(ratings, rating)
 	}
 
-	// 检查遍历过程中是否有错误
-	if err = rows.Err(); err != nil {
+	// 完成率从目标创建当天（按用户时区）开始统计，早于创建日期的评分（如迁移的数据）从第一次评分开始，
+	// 最早从统计范围的起始日期开始
+	for i := range goals {
+		schedule := goals[i].Schedule
+		streaks := schedule.Streaks(dates[goals[i].ID])
+		goals[i].CurrentStreak = schedule.CurrentStreak(streaks, today)
+		goals[i].LongestStreak = models.LongestStreak(streaks)
+
+		start := models.DateOf(goals[i].CreatedAt.In(loc))
+		if goalRated := rated[goals[i].ID]; len(goalRated) > 0 && goalRated[0].Before(start.Time) {
+			start = goalRated[0]
+		}
+		if start.Before(from.Time) {
+			start = from
+		}
+		goals[i].Compliance = schedule.Compliance(rated[goals[i].ID], start, today)
+	}
+	return nil
+}
+
+// checkParentGoal 检查父目标是否存在且属于当前用户，未设置父目标时直接通过
+// 父目标不存在时返回400，不属于当前用户时返回403，此时返回 false
+func checkParentGoal(c *gin.Context, goals store.GoalStore, parentID *int) bool {
+	if parentID == nil {
+		return true
+	}
+	parent, err := goals.GetGoal(c.Request.Context(), *parentID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "父目标不存在"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if parent.OwnerID != auth.CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该目标"})
+		return false
+	}
+	return true
+}
+
+// validateGoal 规范化目标的类别名，并检查类别名、目标星数和标签是否有效
+func validateGoal(goal *models.StarGoal) error {
+	goal.Category = models.NormalizeCategoryName(goal.Category)
+	if goal.Category != "" {
+		if err := validateCategoryName(goal.Category); err != nil {
+			return err
+		}
+	}
+	if goal.TargetStars != nil && *goal.TargetStars < 1 {
+		return errors.New("目标星数必须大于0")
+	}
+	if err := goal.Schedule.Normalize(); err != nil {
+		return err
+	}
+	if len(goal.Tags) > maxGoalTags {
+		return fmt.Errorf("每个目标最多%d个标签", maxGoalTags)
+	}
+	for _, tag := range goal.Tags {
+		if err := validateTagName(models.NormalizeTagName(tag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listGoals 查询一页目标，计算连续评分天数后返回
+func (gc *GoalController) listGoals(c *gin.Context, query store.GoalQuery) {
+	minRating, err := gc.minRating(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := gc.Goals.ListGoals(c.Request.Context(), query)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == nil {
+		err = gc.attachRatingStats(c, page.Goals, minRating, nil)
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 返回评分记录
-	c.JSON(http.StatusOK, ratings)
+	c.JSON(http.StatusOK, page)
+}
+
+// parseGoalQuery 从查询参数中解析目标列表的过滤、排序和分页条件，日期边界按 loc 解析
+func parseGoalQuery(c *gin.Context, loc *time.Location) (store.GoalQuery, error) {
+	query := store.GoalQuery{
+		OwnerID:  auth.CurrentUser(c).ID,
+		Category: c.Query("category"),
+		Sort:     c.DefaultQuery("sort", store.GoalSortCreatedAt),
+		Limit:    defaultGoalsLimit,
+		Cursor:   c.Query("cursor"),
+	}
+
+	// 未指定状态时不显示已归档的目标
+	query.Statuses = c.QueryArray("status")
+	if len(query.Statuses) == 0 {
+		query.Statuses = []string{models.GoalStatusActive, models.GoalStatusPaused, models.GoalStatusCompleted}
+	}
+	for _, status := range query.Statuses {
+		if !models.ValidGoalStatus(status) {
+			return query, fmt.Errorf("无效的目标状态: %s", status)
+		}
+	}
+
+	if !store.ValidGoalSort(query.Sort) {
+		return query, fmt.Errorf("无效的排序字段: %s", query.Sort)
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, errors.New("排序方向只能是 asc 或 desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxGoalsLimit {
+			return query, fmt.Errorf("每页条数必须在1到%d之间", maxGoalsLimit)
+		}
+		query.Limit = limit
+	}
+
+	for name, target := range map[string]**int{"min_stars": &query.MinStars, "max_stars": &query.MaxStars} {
+		if value := c.Query(name); value != "" {
+			stars, err := strconv.Atoi(value)
+			if err != nil {
+				return query, fmt.Errorf("无效的 %s: %s", name, value)
+			}
+			*target = &stars
+		}
+	}
+
+	// 标签过滤默认匹配任意一个标签，tag_mode=all 时需包含全部标签
+	for _, tag := range c.QueryArray("tag") {
+		if tag = models.NormalizeTagName(tag); tag != "" {
+			query.Tags = append(query.Tags, tag)
+		}
+	}
+	switch c.DefaultQuery("tag_mode", "any") {
+	case "any":
+	case "all":
+		query.AllTags = true
+	default:
+		return query, errors.New("tag_mode 只能是 any 或 all")
+	}
+
+	var err error
+	if query.CreatedFrom, err = parseDateBound(c.Query("created_from"), false, loc); err != nil {
+		return query, fmt.Errorf("无效的 created_from: %w", err)
+	}
+	if query.CreatedTo, err = parseDateBound(c.Query("created_to"), true, loc); err != nil {
+		return query, fmt.Errorf("无效的 created_to: %w", err)
+	}
+	return query, nil
+}
+
+// parseDateBound 解析 YYYY-MM-DD 或 RFC3339 格式的时间边界，参数为空时返回 nil
+// YYYY-MM-DD 为 loc 时区的日期，作为上限的日期包含当天，因此返回次日零点
+func parseDateBound(value string, upper bool, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(models.DateLayout, value, loc)
+	if err != nil {
+		return nil, errors.New("日期格式应为 YYYY-MM-DD 或 RFC3339")
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
 }
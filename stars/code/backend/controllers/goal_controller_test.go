@@ -0,0 +1,289 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"starpool/models"
+	"starpool/store"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateGoalPartial(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.register("alice")
+	parent := ts.createGoal(token, gin.H{"title": "健康"})
+	goal := ts.createGoal(token, gin.H{
+		"title":        "跑步",
+		"description":  "每周三次",
+		"category":     "运动",
+		"tags":         []string{"户外", "晨练"},
+		"target_stars": 50,
+		"due_date":     day(30),
+		"parent_id":    parent.ID,
+		"schedule":     gin.H{"type": models.ScheduleWeekdays, "days": []int{1, 3, 5}},
+	})
+	path := fmt.Sprintf("/goals/%d", goal.ID)
+
+	// 只修改标题，其余字段保持不变
+	var updated models.StarGoal
+	ts.expect(http.StatusOK, http.MethodPut, path, token, gin.H{"title": "慢跑"}, &updated)
+	if updated.Title != "慢跑" {
+		t.Fatalf("标题 = %q，期望 慢跑", updated.Title)
+	}
+	if updated.Description != goal.Description || updated.Category != goal.Category {
+		t.Fatalf("描述或类别被修改: %q %q", updated.Description, updated.Category)
+	}
+	if !reflect.DeepEqual(updated.Tags, goal.Tags) {
+		t.Fatalf("标签 = %v，期望 %v", updated.Tags, goal.Tags)
+	}
+	if !reflect.DeepEqual(updated.Schedule, goal.Schedule) {
+		t.Fatalf("评分计划 = %+v，期望 %+v", updated.Schedule, goal.Schedule)
+	}
+	if updated.TargetStars == nil || *updated.TargetStars != 50 {
+		t.Fatalf("目标星数 = %v，期望 50", updated.TargetStars)
+	}
+	if updated.DueDate == nil || updated.DueDate.String() != day(30) {
+		t.Fatalf("截止日期 = %v，期望 %s", updated.DueDate, day(30))
+	}
+	if updated.ParentID == nil || *updated.ParentID != parent.ID {
+		t.Fatalf("父目标 = %v，期望 %d", updated.ParentID, parent.ID)
+	}
+
+	// 显式传 null 清空可以为空的字段，未出现的字段仍然保持不变
+	body := gin.H{"target_stars": nil, "due_date": nil, "parent_id": nil, "tags": []string{}}
+	ts.expect(http.StatusOK, http.MethodPut, path, token, body, &updated)
+	if updated.TargetStars != nil || updated.DueDate != nil || updated.ParentID != nil || len(updated.Tags) != 0 {
+		t.Fatalf("目标星数、截止日期、父目标和标签应被清空: %+v", updated)
+	}
+	if updated.Title != "慢跑" || updated.Schedule.Type != models.ScheduleWeekdays {
+		t.Fatalf("未出现的字段被修改: %+v", updated)
+	}
+
+	// 请求中出现的字段仍然校验
+	ts.expect(http.StatusBadRequest, http.MethodPut, path, token, gin.H{"target_stars": 0}, nil)
+	ts.expect(http.StatusBadRequest, http.MethodPut, path, token, gin.H{"parent_id": goal.ID}, nil)
+}
+
+func TestGoalCreatedBoundsInUserTimezone(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.register("alice")
+	ts.expect(http.StatusOK, http.MethodPut, "/auth/me", token, gin.H{"timezone": "Pacific/Kiritimati"}, nil)
+	goal := ts.createGoal(token, gin.H{"title": "跑步"})
+
+	// 日期边界按用户时区（UTC+14）解析，今天创建的目标落在当天的范围内
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Skip(err)
+	}
+	today := time.Now().In(loc).Format(models.DateLayout)
+	yesterday := time.Now().In(loc).AddDate(0, 0, -1).Format(models.DateLayout)
+	count := func(query string) int {
+		var page store.GoalPage
+		ts.expect(http.StatusOK, http.MethodGet, "/goals?"+query, token, nil, &page)
+		for _, g := range page.Goals {
+			if g.ID != goal.ID {
+				t.Fatalf("意外的目标 %d", g.ID)
+			}
+		}
+		return len(page.Goals)
+	}
+	if n := count("created_from=" + today + "&created_to=" + today); n != 1 {
+		t.Fatalf("今天创建的目标数 = %d，期望 1", n)
+	}
+	if n := count("created_to=" + yesterday); n != 0 {
+		t.Fatalf("昨天及以前创建的目标数 = %d，期望 0", n)
+	}
+}
+
+func TestRatingStatsWindow(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.register("alice")
+	goal := ts.createGoal(token, gin.H{"title": "跑步", "schedule": gin.H{"type": models.ScheduleDaily}})
+
+	// 一年多以前连续评分3天，最近连续评分2天
+	for offset := -400; offset > -403; offset-- {
+		ts.rate(token, goal.ID, 5, day(offset))
+	}
+	ts.rate(token, goal.ID, 5, day(-1))
+	ts.rate(token, goal.ID, 5, day(0))
+
+	// 目标详情只统计最近52周的评分
+	var detail models.StarGoal
+	ts.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/goals/%d", goal.ID), token, nil, &detail)
+	if detail.CurrentStreak != 2 || detail.LongestStreak != 2 {
+		t.Fatalf("当前和最长连续天数 = %d %d，期望 2 2", detail.CurrentStreak, detail.LongestStreak)
+	}
+	if detail.Compliance == nil || *detail.Compliance != 100 {
+		t.Fatalf("完成率 = %v，期望 100（统计范围之前的评分不计入）", detail.Compliance)
+	}
+
+	// 连续评分记录返回所有历史
+	var streaks struct {
+		Longest int             `json:"longest"`
+		Streaks []models.Streak `json:"streaks"`
+	}
+	ts.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/goals/%d/streaks", goal.ID), token, nil, &streaks)
+	if streaks.Longest != 3 || len(streaks.Streaks) != 2 {
+		t.Fatalf("最长连续天数 = %d，区间数 = %d，期望 3 和 2", streaks.Longest, len(streaks.Streaks))
+	}
+}
+
+func TestGoalOwnership(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob := ts.register("alice"), ts.register("bob")
+	goal := ts.createGoal(alice, gin.H{"title": "跑步"})
+	path := fmt.Sprintf("/goals/%d", goal.ID)
+
+	ts.expect(http.StatusUnauthorized, http.MethodGet, path, "", nil, nil)
+	ts.expect(http.StatusForbidden, http.MethodGet, path, bob, nil, nil)
+	ts.expect(http.StatusForbidden, http.MethodPut, path, bob, gin.H{"title": "改名"}, nil)
+	ts.expect(http.StatusForbidden, http.MethodDelete, path, bob, nil, nil)
+	ts.expect(http.StatusForbidden, http.MethodPost, path+"/daily-rating", bob, gin.H{"rating": 5}, nil)
+	ts.expect(http.StatusForbidden, http.MethodGet, path+"/comments", bob, nil, nil)
+	ts.expect(http.StatusForbidden, http.MethodPost, path+"/comments", bob, gin.H{"content": "加油"}, nil)
+	ts.expect(http.StatusOK, http.MethodGet, path, alice, nil, nil)
+
+	// 其他用户的目标不出现在自己的列表中
+	var page store.GoalPage
+	ts.expect(http.StatusOK, http.MethodGet, "/goals", bob, nil, &page)
+	if len(page.Goals) != 0 {
+		t.Fatalf("bob 的目标数 = %d，期望 0", len(page.Goals))
+	}
+}
+
+func TestGoalCursorPagination(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.register("alice")
+	for i := 0; i < 5; i++ {
+		ts.createGoal(token, gin.H{"title": fmt.Sprintf("目标%d", i)})
+	}
+
+	seen := map[int]bool{}
+	cursor, pages := "", 0
+	for {
+		var page store.GoalPage
+		ts.expect(http.StatusOK, http.MethodGet, "/goals?limit=2&cursor="+url.QueryEscape(cursor), token, nil, &page)
+		pages++
+		if len(page.Goals) > 2 {
+			t.Fatalf("每页目标数 = %d，超过 limit", len(page.Goals))
+		}
+		for _, goal := range page.Goals {
+			if seen[goal.ID] {
+				t.Fatalf("目标 %d 在多页中重复出现", goal.ID)
+			}
+			seen[goal.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(seen) != 5 || pages != 3 {
+		t.Fatalf("共取得 %d 个目标、%d 页，期望 5 个目标、3 页", len(seen), pages)
+	}
+
+	// 排序在翻页时保持一致
+	titles := []string{}
+	for cursor = ""; ; {
+		var page store.GoalPage
+		ts.expect(http.StatusOK, http.MethodGet, "/goals?sort=title&order=asc&limit=2&cursor="+url.QueryEscape(cursor), token, nil, &page)
+		for _, goal := range page.Goals {
+			titles = append(titles, goal.Title)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if !reflect.DeepEqual(titles, []string{"目标0", "目标1", "目标2", "目标3", "目标4"}) {
+		t.Fatalf("按标题升序的目标 = %v", titles)
+	}
+
+	// 按类别筛选
+	ts.createGoal(token, gin.H{"title": "跑步", "category": "运动"})
+	var page store.GoalPage
+	ts.expect(http.StatusOK, http.MethodGet, "/goals?category="+url.QueryEscape("运动"), token, nil, &page)
+	if len(page.Goals) != 1 || page.Goals[0].Title != "跑步" {
+		t.Fatalf("类别为运动的目标 = %+v，期望只有跑步", page.Goals)
+	}
+
+	ts.expect(http.StatusBadRequest, http.MethodGet, "/goals?cursor=invalid", token, nil, nil)
+	ts.expect(http.StatusBadRequest, http.MethodGet, "/goals?sort=unknown", token, nil, nil)
+	ts.expect(http.StatusBadRequest, http.MethodGet, "/goals?created_from=2024-13-01", token, nil, nil)
+}
+
+func TestScheduleAndCompliance(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.register("alice")
+	daily := ts.createGoal(token, gin.H{"title": "每天", "schedule": gin.H{"type": models.ScheduleDaily}})
+
+	// 每天的目标今天未评分时出现在今日待办中，评分后消失
+	todayGoals := func() map[int]bool {
+		var response struct {
+			Goals []models.StarGoal `json:"goals"`
+		}
+		ts.expect(http.StatusOK, http.MethodGet, "/today", token, nil, &response)
+		ids := map[int]bool{}
+		for _, goal := range response.Goals {
+			ids[goal.ID] = true
+		}
+		return ids
+	}
+	if !todayGoals()[daily.ID] {
+		t.Fatal("未评分的每日目标应出现在今日待办中")
+	}
+
+	// 补录前天的评分后完成率从前天开始统计：跳过昨天时为 2/3
+	ts.rate(token, daily.ID, 4, day(-2))
+	ts.rate(token, daily.ID, 4, day(0))
+	if todayGoals()[daily.ID] {
+		t.Fatal("今天已评分的目标不应出现在今日待办中")
+	}
+	compliance := func() float64 {
+		var goal models.StarGoal
+		ts.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/goals/%d", daily.ID), token, nil, &goal)
+		if goal.Compliance == nil {
+			t.Fatal("完成率不应为空")
+		}
+		return *goal.Compliance
+	}
+	if rate := compliance(); rate != 66.7 {
+		t.Fatalf("完成率 = %v，期望 66.7", rate)
+	}
+	ts.rate(token, daily.ID, 3, day(-1))
+	if rate := compliance(); rate != 100 {
+		t.Fatalf("完成率 = %v，期望 100", rate)
+	}
+
+	// 今天不在计划中的目标不出现在今日待办中
+	weekday := int(time.Now().UTC().Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	days := []int{}
+	for d := 1; d <= 7; d++ {
+		if d != weekday {
+			days = append(days, d)
+		}
+	}
+	weekdays := ts.createGoal(token, gin.H{"title": "不含今天", "schedule": gin.H{"type": models.ScheduleWeekdays, "days": days}})
+	if todayGoals()[weekdays.ID] {
+		t.Fatal("今天不在计划中的目标不应出现在今日待办中")
+	}
+
+	// 每周计划在本周评分次数达到要求后不再出现在今日待办中
+	weekly := ts.createGoal(token, gin.H{"title": "每周一次", "schedule": gin.H{"type": models.ScheduleWeekly, "times": 1}})
+	if !todayGoals()[weekly.ID] {
+		t.Fatal("本周尚未评分的每周目标应出现在今日待办中")
+	}
+	ts.rate(token, weekly.ID, 3, day(0))
+	if todayGoals()[weekly.ID] {
+		t.Fatal("本周已达到评分次数的每周目标不应出现在今日待办中")
+	}
+
+	ts.expect(http.StatusBadRequest, http.MethodPost, "/goals", token, gin.H{"title": "无效", "schedule": gin.H{"type": "monthly"}}, nil)
+}
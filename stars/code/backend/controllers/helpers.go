@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Nullable 请求中可以省略或为 null 的字段，用于只更新请求中出现的字段
+// Set 表示请求中出现了该字段，此时 Value 为空表示 null
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON 记录字段已出现，null 解析为空值
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	n.Value = nil
+	if string(data) == "null" {
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
+
+// respondStoreError 将存储层错误转换为HTTP响应，记录不存在时返回404，并发冲突、目标状态不允许该操作、星数余额不足、兑换申请已处理和评论已删除时返回409
+func respondStoreError(c *gin.Context, err error, notFoundMessage string) {
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFoundMessage})
+		return
+	}
+	if errors.Is(err, store.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrConflict.Error()})
+		return
+	}
+	if errors.Is(err, store.ErrGoalNotActive) {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrGoalNotActive.Error()})
+		return
+	}
+	if errors.Is(err, store.ErrInsufficientStars) {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrInsufficientStars.Error()})
+		return
+	}
+	if errors.Is(err, store.ErrRedemptionDecided) {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrRedemptionDecided.Error()})
+		return
+	}
+	if errors.Is(err, store.ErrCommentDeleted) {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrCommentDeleted.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// authorizeGoal 查询目标并检查当前用户是否为目标的所属用户
+// 目标不存在时返回404，不属于当前用户时返回403，此时第二个返回值为 false
+func authorizeGoal(c *gin.Context, goals store.GoalStore, goalID int) (*models.StarGoal, bool) {
+	goal, err := goals.GetGoal(c.Request.Context(), goalID)
+	if err != nil {
+		respondStoreError(c, err, "目标未找到")
+		return nil, false
+	}
+	if goal.OwnerID != auth.CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该目标"})
+		return nil, false
+	}
+	return goal, true
+}
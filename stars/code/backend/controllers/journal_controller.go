@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JournalController 处理日记相关的HTTP请求
+type JournalController struct {
+	Ratings store.RatingStore // 每日评分存储
+}
+
+// GetJournal 获取日记
+// @Summary 获取日记
+// @Description 按日期升序获取当前用户所有目标带备注的每日评分，附带目标标题、评分和心情，备注为 Markdown 原文
+// @Tags journal
+// @Produce json
+// @Param from query string false "起始日期，YYYY-MM-DD"
+// @Param to query string false "结束日期（含），YYYY-MM-DD"
+// @Success 200 {array} models.JournalEntry
+// @Failure 400 {object} map[string]string
+// @Router /journal [get]
+func (jc *JournalController) GetJournal(c *gin.Context) {
+	// 解析查询参数
+	var from, to *models.Date
+	if value := c.Query("from"); value != "" {
+		date, err := models.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 from: " + err.Error()})
+			return
+		}
+		from = &date
+	}
+	if value := c.Query("to"); value != "" {
+		date, err := models.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 to: " + err.Error()})
+			return
+		}
+		to = &date
+	}
+	if from != nil && to != nil && from.After(to.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "起始日期不能晚于结束日期"})
+		return
+	}
+
+	entries, err := jc.Ratings.ListJournal(c.Request.Context(), auth.CurrentUser(c).ID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 排行榜的默认条数和最大条数
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// LeaderboardController 处理排行榜相关的HTTP请求
+type LeaderboardController struct {
+	Ratings store.RatingStore // 每日评分存储
+	Users   store.UserStore   // 用户存储，用于读取当前用户的排行榜设置
+	Clock   *Clock            // 按用户时区计算统计周期
+}
+
+// GetLeaderboard 获取排行榜
+// @Summary 获取排行榜
+// @Description 按统计周期内每日评分的星数合计（而不是目标当前的星数）对所有用户、目标或类别排名，星数相同时名次相同；
+// @Description 退出排行榜的用户及其目标不参与排名，未分类的目标不参与类别排名。本周从周一开始，周期按当前用户的时区计算
+// @Tags leaderboard
+// @Produce json
+// @Param by query string false "排名对象：users（默认）、goals 或 categories"
+// @Param period query string false "统计周期：week（默认）、month 或 all"
+// @Param limit query int false "返回条数，默认10，最多100"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /leaderboard [get]
+func (lc *LeaderboardController) GetLeaderboard(c *gin.Context) {
+	// 解析查询参数
+	by := c.DefaultQuery("by", models.LeaderboardUsers)
+	if !models.ValidLeaderboard(by) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "排名对象只能是 users、goals 或 categories"})
+		return
+	}
+	period := c.DefaultQuery("period", models.PeriodWeek)
+	today := lc.Clock.Today(c)
+	from, err := models.PeriodStart(period, today)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "统计周期只能是 week、month 或 all"})
+		return
+	}
+	limit := defaultLeaderboardLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxLeaderboardLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("返回条数必须在1到%d之间", maxLeaderboardLimit)})
+			return
+		}
+	}
+
+	// 读取当前用户的排行榜设置
+	ctx := c.Request.Context()
+	user, err := lc.Users.GetUser(ctx, auth.CurrentUser(c).ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 汇总评分并排名
+	entries, err := lc.Ratings.SumRatingsForLeaderboard(ctx, store.LeaderboardQuery{By: by, From: from, To: &today})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"by":        by,
+		"period":    period,
+		"from":      from,
+		"to":        today,
+		"opted_out": user.LeaderboardOptOut,
+		"entries":   models.RankLeaderboard(entries, limit),
+	})
+}
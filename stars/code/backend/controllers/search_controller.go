@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"starpool/auth"
+	"starpool/search"
+	"starpool/store"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 搜索结果的默认条数和最大条数
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	// searchCandidateLimit 参与排序的目标和评论候选各自的最大数量
+	searchCandidateLimit = 200
+	// maxSearchQueryLength 搜索关键词的最大字符数
+	maxSearchQueryLength = 100
+)
+
+// SearchController 处理全文搜索相关的HTTP请求
+type SearchController struct {
+	Documents store.SearchStore // 搜索存储
+}
+
+// Search 搜索目标和评论
+// @Summary 搜索目标和评论
+// @Description 在当前用户的目标标题、描述和评论内容中搜索，按相关度返回带高亮摘要的结果，评论结果附带所属目标
+// @Tags search
+// @Produce json
+// @Param q query string true "搜索关键词"
+// @Param limit query int false "结果条数，默认20，最大50"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /search [get]
+func (sc *SearchController) Search(c *gin.Context) {
+	// 获取查询参数
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "搜索关键词不能为空"})
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("搜索关键词不能超过%d个字符", maxSearchQueryLength)})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("结果条数必须在1到%d之间", maxSearchLimit)})
+			return
+		}
+	}
+
+	// 查询候选文档并按相关度排序
+	hits := []search.Hit{}
+	if terms := search.Tokenize(query); len(terms) > 0 {
+		documents, err := sc.Documents.SearchDocuments(c.Request.Context(), auth.CurrentUser(c).ID, terms, searchCandidateLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		hits = search.Rank(query, documents, limit)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query": query,
+		"total": len(hits),
+		"hits":  hits,
+	})
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStarAdjustment 单次手动调整最多扣减的星数
+const maxStarAdjustment = 100
+
+// StarController 处理星数流水相关的HTTP请求
+type StarController struct {
+	Goals  store.GoalStore   // 目标存储
+	Ledger store.LedgerStore // 星数流水存储
+}
+
+// StarAdjustmentRequest 手动调整星数的请求体
+type StarAdjustmentRequest struct {
+	Amount int    `json:"amount"` // 调整的星数，只能为负数，即扣减星数
+	Reason string `json:"reason"` // 调整原因
+}
+
+// GetStarHistory 获取目标的星数流水
+// @Summary 获取目标的星数流水
+// @Description 按时间顺序返回目标的星数变动记录及每笔变动后的余额
+// @Tags stars
+// @Produce json
+// @Param id path int true "目标ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /goals/{id}/stars/history [get]
+func (sc *StarController) GetStarHistory(c *gin.Context) {
+	// 获取路径参数
+	goalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, sc.Goals, goalId); !ok {
+		return
+	}
+
+	// 查询星数流水
+	transactions, err := sc.Ledger.ListStarTransactions(c.Request.Context(), goalId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	balance := 0
+	if len(transactions) > 0 {
+		balance = transactions[len(transactions)-1].Balance
+	}
+
+	// 返回流水和当前余额
+	c.JSON(http.StatusOK, gin.H{
+		"goal_id":      goalId,
+		"balance":      balance,
+		"transactions": transactions,
+	})
+}
+
+// AdjustStars 手动调整目标的星数
+// @Summary 手动调整目标的星数
+// @Description 为目标追加一笔扣减星数的调整流水，用于纠正多记的星数；星数只能通过评分获得，不能手动增加，
+// @Description 单次最多扣减100星，扣减后目标星数和用户的星数余额都不能为负，返回的流水附带扣减后目标的星数余额
+// @Tags stars
+// @Accept json
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param adjustment body StarAdjustmentRequest true "调整信息"
+// @Success 201 {object} models.StarTransaction
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /goals/{id}/stars/adjustments [post]
+func (sc *StarController) AdjustStars(c *gin.Context) {
+	// 获取路径参数
+	goalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return
+	}
+
+	// 解析请求体
+	var request StarAdjustmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 验证调整值和原因
+	if request.Amount >= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能扣减星数，调整的星数必须为负数"})
+		return
+	}
+	if request.Amount < -maxStarAdjustment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("单次最多扣减%d星", maxStarAdjustment)})
+		return
+	}
+	if request.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "必须填写调整原因"})
+		return
+	}
+
+	// 检查目标是否存在且属于当前用户
+	if _, ok := authorizeGoal(c, sc.Goals, goalId); !ok {
+		return
+	}
+
+	// 追加调整流水
+	transaction := models.StarTransaction{
+		GoalID: &goalId,
+		Type:   models.StarTransactionAdjust,
+		Amount: request.Amount,
+		Reason: request.Reason,
+		Source: models.StarSourceManual,
+	}
+	if err := sc.Ledger.AddStarTransaction(c.Request.Context(), &transaction); err != nil {
+		respondStoreError(c, err, "目标未找到")
+		return
+	}
+
+	// 返回创建的流水及扣减后的余额
+	c.JSON(http.StatusCreated, transaction)
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultStatsDays 统计接口未指定日期时统计的天数
+const defaultStatsDays = 30
+
+// 热力图支持的年份范围
+const (
+	minHeatmapYear = 2000
+	maxHeatmapYear = 2100
+)
+
+// StatsController 处理评分和星数统计相关的HTTP请求
+type StatsController struct {
+	Goals   store.GoalStore   // 目标存储
+	Ratings store.RatingStore // 每日评分存储
+	Ledger  store.LedgerStore // 星数流水存储
+	Clock   *Clock            // 按用户时区计算日期
+}
+
+// GetStats 获取评分和星数统计
+// @Summary 获取评分和星数统计
+// @Description 统计当前用户在 from 到 to（含）之间的评分和星数：所有目标及每个目标的评分次数、平均评分和1-5星分布，
+// @Description 每周（周一开始）和每月的星数变动合计（按流水时间），周一到周日的平均评分及最好和最差的星期几，
+// @Description 以及后半段平均评分比前半段提高最多的目标；未指定日期时统计截至今天的最近30天
+// @Tags stats
+// @Produce json
+// @Param from query string false "起始日期，YYYY-MM-DD，默认为结束日期前29天"
+// @Param to query string false "结束日期，YYYY-MM-DD，默认为当前用户时区的今天"
+// @Success 200 {object} models.Stats
+// @Failure 400 {object} map[string]string
+// @Router /stats [get]
+func (sc *StatsController) GetStats(c *gin.Context) {
+	// 解析查询参数
+	var err error
+	to := sc.Clock.Today(c)
+	if value := c.Query("to"); value != "" {
+		if to, err = models.ParseDate(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 to: " + err.Error()})
+			return
+		}
+	}
+	from := models.Date{Time: to.AddDate(0, 0, 1-defaultStatsDays)}
+	if value := c.Query("from"); value != "" {
+		if from, err = models.ParseDate(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 from: " + err.Error()})
+			return
+		}
+	}
+	if from.After(to.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "起始日期不能晚于结束日期"})
+		return
+	}
+	if models.CountBuckets(from, to, models.GranularityWeek) > maxRatingBuckets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("统计范围过大，最多%d周", maxRatingBuckets)})
+		return
+	}
+
+	// 查询目标、评分和星数流水，流水时间按用户时区换算为日期
+	ctx, ownerID, loc := c.Request.Context(), auth.CurrentUser(c).ID, sc.Clock.Location(c)
+	page, err := sc.Goals.ListGoals(ctx, store.GoalQuery{OwnerID: ownerID, Sort: store.GoalSortCreatedAt})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ratings, err := sc.Ratings.ListUserRatings(ctx, ownerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	since := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	until := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+	transactions, err := sc.Ledger.ListUserTransactions(ctx, ownerID, since, until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	changes := make([]models.StarChange, 0, len(transactions))
+	for _, transaction := range transactions {
+		changes = append(changes, models.StarChange{
+			GoalID: *transaction.GoalID,
+			Date:   models.DateOf(transaction.CreatedAt.In(loc)),
+			Amount: transaction.Amount,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.ComputeStats(ratings, changes, page.Goals, from, to))
+}
+
+// GetHeatmap 获取一年的评分热力图
+// @Summary 获取一年的评分热力图
+// @Description 按天返回当前用户某一年的评分强度，用于绘制日历热力图：levels 为每天的强度级别（0 为没有评分，1-4 按当天星数占全年单日最大值的比例划分），
+// @Description stars 为每天的评分星数合计，两个数组都从1月1日开始每天一项；可以按目标或类别过滤
+// @Tags stats
+// @Produce json
+// @Param year query int false "年份，默认为当前用户时区的今年"
+// @Param goal_id query int false "只统计该目标的评分"
+// @Param category query string false "只统计该类别下目标的评分"
+// @Success 200 {object} models.Heatmap
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /heatmap [get]
+func (sc *StatsController) GetHeatmap(c *gin.Context) {
+	// 解析查询参数
+	year := sc.Clock.Today(c).Year()
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < minHeatmapYear || parsed > maxHeatmapYear {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("年份必须在%d-%d之间", minHeatmapYear, maxHeatmapYear)})
+			return
+		}
+		year = parsed
+	}
+	query := store.HeatmapQuery{
+		OwnerID:  auth.CurrentUser(c).ID,
+		From:     models.Date{Time: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		To:       models.Date{Time: time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		Category: models.NormalizeCategoryName(c.Query("category")),
+	}
+	if value := c.Query("goal_id"); value != "" {
+		goalID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+			return
+		}
+		// 检查目标是否存在且属于当前用户
+		if _, ok := authorizeGoal(c, sc.Goals, goalID); !ok {
+			return
+		}
+		query.GoalID = goalID
+	}
+
+	totals, err := sc.Ratings.SumRatingsByDay(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.BuildHeatmap(year, totals))
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 标签名的最大长度和每个目标的最大标签数
+const (
+	maxTagNameLength = 50
+	maxGoalTags      = 20
+)
+
+// TagController 处理标签相关的HTTP请求
+type TagController struct {
+	Tags store.TagStore // 标签存储
+}
+
+// TagRenameRequest 标签重命名请求
+type TagRenameRequest struct {
+	Name string `json:"name" binding:"required"` // 新标签名
+}
+
+// TagMergeRequest 标签合并请求
+type TagMergeRequest struct {
+	From []int `json:"from" binding:"required"` // 被合并的标签ID，合并后删除
+	Into int   `json:"into" binding:"required"` // 合并到的标签ID
+}
+
+// GetTags 获取所有标签
+// @Summary 获取所有标签
+// @Description 获取当前用户的所有标签及使用各标签的目标数 goal_count，按名称排序
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Router /tags [get]
+func (tc *TagController) GetTags(c *gin.Context) {
+	tags, err := tc.Tags.ListTags(c.Request.Context(), auth.CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// RenameTag 重命名标签
+// @Summary 重命名标签
+// @Description 重命名标签，使用该标签的目标随之更新；已有同名标签时返回409，可改用合并接口
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "标签ID"
+// @Param tag body TagRenameRequest true "新标签名"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tags/{id} [put]
+func (tc *TagController) RenameTag(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	// 解析请求体
+	var request TagRenameRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := models.NormalizeTagName(request.Name)
+	if err := validateTagName(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 检查标签是否存在且属于当前用户
+	if _, ok := tc.authorizeTag(c, id); !ok {
+		return
+	}
+
+	// 重命名标签
+	if err := tc.Tags.RenameTag(c.Request.Context(), id, name); err != nil {
+		if errors.Is(err, store.ErrDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "已存在同名标签，可以将两个标签合并"})
+			return
+		}
+		respondStoreError(c, err, "标签未找到")
+		return
+	}
+
+	// 返回更新后的标签
+	tag, err := tc.Tags.GetTag(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "标签未找到")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTags 合并标签
+// @Summary 合并标签
+// @Description 将 from 中的标签合并到 into 标签：使用 from 标签的目标改为使用 into 标签，然后删除 from 标签
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param merge body TagMergeRequest true "要合并的标签"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /tags/merge [post]
+func (tc *TagController) MergeTags(c *gin.Context) {
+	// 解析请求体
+	var request TagMergeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(request.From) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "至少需要一个被合并的标签"})
+		return
+	}
+
+	// 检查所有标签是否存在且属于当前用户
+	for _, id := range append([]int{request.Into}, request.From...) {
+		if _, ok := tc.authorizeTag(c, id); !ok {
+			return
+		}
+	}
+
+	// 合并标签
+	if err := tc.Tags.MergeTags(c.Request.Context(), request.From, request.Into); err != nil {
+		respondStoreError(c, err, "标签未找到")
+		return
+	}
+
+	// 返回合并后的标签
+	tag, err := tc.Tags.GetTag(c.Request.Context(), request.Into)
+	if err != nil {
+		respondStoreError(c, err, "标签未找到")
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag 删除标签
+// @Summary 删除标签
+// @Description 删除标签并从所有目标上移除，目标本身不受影响
+// @Tags tags
+// @Param id path int true "标签ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /tags/{id} [delete]
+func (tc *TagController) DeleteTag(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	// 检查标签是否存在且属于当前用户
+	if _, ok := tc.authorizeTag(c, id); !ok {
+		return
+	}
+
+	// 删除标签
+	if err := tc.Tags.DeleteTag(c.Request.Context(), id); err != nil {
+		respondStoreError(c, err, "标签未找到")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authorizeTag 查询标签并检查当前用户是否为标签的所属用户
+// 标签不存在时返回404，不属于当前用户时返回403，此时第二个返回值为 false
+func (tc *TagController) authorizeTag(c *gin.Context, id int) (*models.Tag, bool) {
+	tag, err := tc.Tags.GetTag(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "标签未找到")
+		return nil, false
+	}
+	if tag.OwnerID != auth.CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该标签"})
+		return nil, false
+	}
+	return tag, true
+}
+
+// validateTagName 检查规范化后的标签名长度是否有效
+func validateTagName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxTagNameLength {
+		return fmt.Errorf("标签名长度必须在1到%d个字符之间", maxTagNameLength)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"starpool/auth"
+	"starpool/models"
+	"starpool/store"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 心愿标题和描述的最大长度
+const (
+	maxWishTitleLength       = 100
+	maxWishDescriptionLength = 1000
+)
+
+// WishController 处理心愿池和心愿兑换相关的HTTP请求
+type WishController struct {
+	Wishes store.WishStore // 心愿存储
+	Users  store.UserStore // 用户存储，用于按用户名查找审批人
+}
+
+// WishRequest 创建或更新心愿的请求
+type WishRequest struct {
+	Title       string `json:"title" binding:"required"` // 心愿标题
+	Description string `json:"description"`              // 心愿描述
+	Price       int    `json:"price"`                    // 兑换所需的星数，必须大于0
+	Approver    string `json:"approver"`                 // 审批人的用户名，为空表示兑换无需审批
+}
+
+// GetWishes 获取心愿池
+// @Summary 获取心愿池
+// @Description 获取当前用户的所有心愿，按创建顺序排列
+// @Tags wishes
+// @Produce json
+// @Success 200 {array} models.Wish
+// @Router /wishes [get]
+func (wc *WishController) GetWishes(c *gin.Context) {
+	wishes, err := wc.Wishes.ListWishes(c.Request.Context(), auth.CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, wishes)
+}
+
+// CreateWish 创建新心愿
+// @Summary 创建新心愿
+// @Description 在心愿池中添加一个心愿，price 为兑换所需的星数；设置审批人 approver（另一个用户的用户名）时，兑换需要审批人同意
+// @Tags wishes
+// @Accept json
+// @Produce json
+// @Param wish body WishRequest true "心愿信息"
+// @Success 201 {object} models.Wish
+// @Failure 400 {object} map[string]string
+// @Router /wishes [post]
+func (wc *WishController) CreateWish(c *gin.Context) {
+	// 解析请求体
+	var request WishRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	wish, ok := wc.wish(c, request)
+	if !ok {
+		return
+	}
+
+	// 保存心愿，所属用户为当前用户
+	wish.OwnerID = auth.CurrentUser(c).ID
+	if err := wc.Wishes.CreateWish(c.Request.Context(), wish); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 返回创建的心愿
+	c.JSON(http.StatusCreated, wish)
+}
+
+// UpdateWish 更新心愿
+// @Summary 更新心愿
+// @Description 更新心愿的标题、描述、价格和审批人，已有的兑换记录保持兑换时的标题、价格和审批人
+// @Tags wishes
+// @Accept json
+// @Produce json
+// @Param id path int true "心愿ID"
+// @Param wish body WishRequest true "更新的心愿信息"
+// @Success 200 {object} models.Wish
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /wishes/{id} [put]
+func (wc *WishController) UpdateWish(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的心愿ID"})
+		return
+	}
+
+	// 解析请求体
+	var request WishRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	wish, ok := wc.wish(c, request)
+	if !ok {
+		return
+	}
+
+	// 检查心愿是否存在且属于当前用户
+	if _, ok := wc.authorizeWish(c, id); !ok {
+		return
+	}
+
+	// 更新心愿
+	wish.ID = id
+	if err := wc.Wishes.UpdateWish(c.Request.Context(), wish); err != nil {
+		respondStoreError(c, err, "心愿未找到")
+		return
+	}
+
+	// 返回更新后的心愿
+	updated, err := wc.Wishes.GetWish(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "心愿未找到")
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteWish 删除心愿
+// @Summary 删除心愿
+// @Description 从心愿池中删除心愿，已有的兑换记录保留，等待审批的兑换仍可由审批人处理
+// @Tags wishes
+// @Param id path int true "心愿ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /wishes/{id} [delete]
+func (wc *WishController) DeleteWish(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的心愿ID"})
+		return
+	}
+
+	// 检查心愿是否存在且属于当前用户
+	if _, ok := wc.authorizeWish(c, id); !ok {
+		return
+	}
+
+	// 删除心愿
+	if err := wc.Wishes.DeleteWish(c.Request.Context(), id); err != nil {
+		respondStoreError(c, err, "心愿未找到")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RedeemWish 兑换心愿
+// @Summary 兑换心愿
+// @Description 用星数兑换心愿：检查并从 GET /stars 返回的星数余额中扣除心愿的价格，记录兑换；
+// @Description 心愿设置了审批人时兑换等待审批人同意（status 为 pending，被拒绝时退还星数），否则直接兑现（status 为 granted）；余额不足时返回409
+// @Tags wishes
+// @Produce json
+// @Param id path int true "心愿ID"
+// @Success 201 {object} models.Redemption
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /wishes/{id}/redeem [post]
+func (wc *WishController) RedeemWish(c *gin.Context) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的心愿ID"})
+		return
+	}
+
+	// 检查心愿是否存在且属于当前用户
+	if _, ok := wc.authorizeWish(c, id); !ok {
+		return
+	}
+
+	// 扣除星数并记录兑换
+	redemption, err := wc.Wishes.RedeemWish(c.Request.Context(), id, auth.CurrentUser(c).ID)
+	if err != nil {
+		respondStoreError(c, err, "心愿未找到")
+		return
+	}
+	c.JSON(http.StatusCreated, redemption)
+}
+
+// GetRedemptions 获取兑换记录
+// @Summary 获取兑换记录
+// @Description 按兑换时间倒序获取当前用户的心愿兑换记录，可按状态 status 过滤
+// @Tags wishes
+// @Produce json
+// @Param status query string false "兑换状态：pending、granted 或 rejected"
+// @Success 200 {array} models.Redemption
+// @Failure 400 {object} map[string]string
+// @Router /redemptions [get]
+func (wc *WishController) GetRedemptions(c *gin.Context) {
+	wc.listRedemptions(c, store.RedemptionQuery{UserID: auth.CurrentUser(c).ID, Status: c.Query("status")})
+}
+
+// GetApprovals 获取待我审批的兑换
+// @Summary 获取待我审批的兑换
+// @Description 按兑换时间倒序获取审批人为当前用户的心愿兑换，默认只返回等待审批的兑换，status=all 时返回全部
+// @Tags wishes
+// @Produce json
+// @Param status query string false "兑换状态：pending（默认）、granted、rejected 或 all"
+// @Success 200 {array} models.Redemption
+// @Failure 400 {object} map[string]string
+// @Router /redemptions/approvals [get]
+func (wc *WishController) GetApprovals(c *gin.Context) {
+	status := c.DefaultQuery("status", models.RedemptionPending)
+	if status == "all" {
+		status = ""
+	}
+	wc.listRedemptions(c, store.RedemptionQuery{ApproverID: auth.CurrentUser(c).ID, Status: status})
+}
+
+// ApproveRedemption 同意兑换
+// @Summary 同意兑换
+// @Description 审批人同意等待审批的心愿兑换，心愿兑现
+// @Tags wishes
+// @Produce json
+// @Param id path int true "兑换记录ID"
+// @Success 200 {object} models.Redemption
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /redemptions/{id}/approve [post]
+func (wc *WishController) ApproveRedemption(c *gin.Context) {
+	wc.decideRedemption(c, true)
+}
+
+// RejectRedemption 拒绝兑换
+// @Summary 拒绝兑换
+// @Description 审批人拒绝等待审批的心愿兑换，扣除的星数退还给兑换的用户
+// @Tags wishes
+// @Produce json
+// @Param id path int true "兑换记录ID"
+// @Success 200 {object} models.Redemption
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /redemptions/{id}/reject [post]
+func (wc *WishController) RejectRedemption(c *gin.Context) {
+	wc.decideRedemption(c, false)
+}
+
+// listRedemptions 检查状态参数并返回满足条件的兑换记录
+func (wc *WishController) listRedemptions(c *gin.Context, query store.RedemptionQuery) {
+	switch query.Status {
+	case "", models.RedemptionPending, models.RedemptionGranted, models.RedemptionRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的兑换状态: " + query.Status})
+		return
+	}
+
+	redemptions, err := wc.Wishes.ListRedemptions(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, redemptions)
+}
+
+// decideRedemption 审批人同意或拒绝兑换
+func (wc *WishController) decideRedemption(c *gin.Context, approve bool) {
+	// 获取路径参数
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的兑换记录ID"})
+		return
+	}
+
+	// 检查当前用户是否为该兑换的审批人
+	redemption, err := wc.Wishes.GetRedemption(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "兑换记录未找到")
+		return
+	}
+	if redemption.ApproverID == nil || *redemption.ApproverID != auth.CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权审批该兑换"})
+		return
+	}
+
+	// 处理兑换并返回处理后的记录
+	if err := wc.Wishes.DecideRedemption(c.Request.Context(), id, approve); err != nil {
+		respondStoreError(c, err, "兑换记录未找到")
+		return
+	}
+	redemption, err = wc.Wishes.GetRedemption(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "兑换记录未找到")
+		return
+	}
+	c.JSON(http.StatusOK, redemption)
+}
+
+// authorizeWish 查询心愿并检查当前用户是否为心愿的所属用户
+// 心愿不存在时返回404，不属于当前用户时返回403，此时第二个返回值为 false
+func (wc *WishController) authorizeWish(c *gin.Context, id int) (*models.Wish, bool) {
+	wish, err := wc.Wishes.GetWish(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err, "心愿未找到")
+		return nil, false
+	}
+	if wish.OwnerID != auth.CurrentUser(c).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该心愿"})
+		return nil, false
+	}
+	return wish, true
+}
+
+// wish 检查请求并转换为心愿，按用户名查找审批人；请求无效时返回400，此时第二个返回值为 false
+func (wc *WishController) wish(c *gin.Context, r WishRequest) (*models.Wish, bool) {
+	wish := &models.Wish{
+		Title:       strings.TrimSpace(r.Title),
+		Description: r.Description,
+		Price:       r.Price,
+	}
+	if err := validateWish(wish); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	approver := strings.TrimSpace(r.Approver)
+	if approver == "" {
+		return wish, true
+	}
+	user, err := wc.Users.GetUserByUsername(c.Request.Context(), approver)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "审批人不存在: " + approver})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if user.ID == auth.CurrentUser(c).ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "审批人不能是自己"})
+		return nil, false
+	}
+	wish.ApproverID, wish.Approver = &user.ID, user.Username
+	return wish, true
+}
+
+// validateWish 检查心愿的标题、描述和价格是否有效
+func validateWish(wish *models.Wish) error {
+	if wish.Title == "" || utf8.RuneCountInString(wish.Title) > maxWishTitleLength {
+		return fmt.Errorf("心愿标题长度必须在1到%d个字符之间", maxWishTitleLength)
+	}
+	if utf8.RuneCountInString(wish.Description) > maxWishDescriptionLength {
+		return fmt.Errorf("心愿描述不能超过%d个字符", maxWishDescriptionLength)
+	}
+	if wish.Price < 1 {
+		return errors.New("心愿价格必须大于0")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"starpool/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedemptionApproval(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob := ts.register("alice"), ts.register("bob")
+	goal := ts.createGoal(alice, gin.H{"title": "跑步"})
+	ts.rate(alice, goal.ID, 5, day(0))
+
+	var wish models.Wish
+	ts.expect(http.StatusCreated, http.MethodPost, "/wishes", alice, gin.H{"title": "看电影", "price": 3, "approver": "bob"}, &wish)
+	ts.expect(http.StatusForbidden, http.MethodPost, fmt.Sprintf("/wishes/%d/redeem", wish.ID), bob, nil, nil)
+	redeem := func() models.Redemption {
+		var redemption models.Redemption
+		ts.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/wishes/%d/redeem", wish.ID), alice, nil, &redemption)
+		return redemption
+	}
+
+	// 兑换时先扣除星数，审批人拒绝后退还
+	redemption := redeem()
+	if redemption.Status != models.RedemptionPending {
+		t.Fatalf("兑换状态 = %s，期望 %s", redemption.Status, models.RedemptionPending)
+	}
+	if stars := ts.stars(alice); stars != 2 {
+		t.Fatalf("兑换后星数 = %d，期望 2", stars)
+	}
+	ts.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/redemptions/%d/reject", redemption.ID), bob, nil, &redemption)
+	if redemption.Status != models.RedemptionRejected {
+		t.Fatalf("兑换状态 = %s，期望 %s", redemption.Status, models.RedemptionRejected)
+	}
+	if stars := ts.stars(alice); stars != 5 {
+		t.Fatalf("拒绝后星数 = %d，期望 5", stars)
+	}
+
+	// 只有审批人可以同意，同意后不退还，已审批的兑换不能再次审批
+	redemption = redeem()
+	approve := fmt.Sprintf("/redemptions/%d/approve", redemption.ID)
+	ts.expect(http.StatusForbidden, http.MethodPost, approve, alice, nil, nil)
+	ts.expect(http.StatusOK, http.MethodPost, approve, bob, nil, &redemption)
+	if redemption.Status != models.RedemptionGranted {
+		t.Fatalf("兑换状态 = %s，期望 %s", redemption.Status, models.RedemptionGranted)
+	}
+	if stars := ts.stars(alice); stars != 2 {
+		t.Fatalf("同意后星数 = %d，期望 2", stars)
+	}
+	ts.expect(http.StatusConflict, http.MethodPost, approve, bob, nil, nil)
+	ts.expect(http.StatusConflict, http.MethodPost, fmt.Sprintf("/redemptions/%d/reject", redemption.ID), bob, nil, nil)
+
+	// 余额不足时不能兑换
+	ts.expect(http.StatusConflict, http.MethodPost, fmt.Sprintf("/wishes/%d/redeem", wish.ID), alice, nil, nil)
+	if stars := ts.stars(alice); stars != 2 {
+		t.Fatalf("兑换失败后星数 = %d，期望 2", stars)
+	}
+}
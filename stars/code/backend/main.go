@@ -2,8 +2,11 @@ package main
 
 import (
 	"log"
+	"os"
+	"starpool/auth"
 	"starpool/config"
 	"starpool/routes"
+	"starpool/store"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -11,8 +14,25 @@ import (
 )
 
 func main() {
-	// 初始化数据库连接
-	config.ConnectDB()
+	// 处理迁移子命令
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(os.Args[2:])
+		return
+	}
+
+	// 初始化存储：DB_DRIVER=memory 时使用内存存储，否则连接 MySQL 或 SQLite
+	var s store.Store
+	if os.Getenv("DB_DRIVER") == "memory" {
+		log.Println("使用内存存储，数据不会持久化")
+		s = store.NewMemoryStore()
+	} else {
+		config.ConnectDB()
+		prepareSchema()
+		s = store.NewSQLStore(config.DB, config.DBDriver)
+	}
+
+	// 初始化访问令牌签发
+	tokens := auth.NewTokenManager(config.JWTSecret(), config.JWTTTL())
 
 	// 创建gin路由器
 	router := gin.Default()
@@ -30,7 +50,8 @@ func main() {
 	router.Use(cors.New(config))
 
 	// 注册路由
-	routes.RegisterGoalRoutes(router)
+	routes.RegisterAuthRoutes(router, s, tokens)
+	routes.RegisterGoalRoutes(router, s, tokens)
 
 	// 启动服务器
 	log.Println("服务器启动在端口 8080 ，模式为 DebugMode")
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"starpool/config"
+	"starpool/migrations"
+	"strconv"
+)
+
+// prepareSchema 在启动服务前检查表结构版本，按配置自动应用待执行的迁移
+// 数据库版本高于程序支持的版本时拒绝启动
+func prepareSchema() {
+	ctx := context.Background()
+	migrator, err := migrations.New(config.DB, config.DBDriver, config.Timezone())
+	if err != nil {
+		log.Fatal("加载迁移脚本失败: ", err)
+	}
+
+	pending, err := migrator.Check(ctx)
+	if err != nil {
+		log.Fatal("表结构版本检查失败: ", err)
+	}
+	if pending == 0 {
+		return
+	}
+
+	if !config.AutoMigrate() {
+		log.Fatalf("有 %d 个待执行的迁移，请先运行 `migrate up` 或设置 DB_AUTO_MIGRATE=true", pending)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, migration := range applied {
+		log.Printf("已应用迁移 %04d_%s", migration.Version, migration.Name)
+	}
+}
+
+// runMigrateCommand 处理 `migrate up|down [步数]|status` 子命令
+func runMigrateCommand(args []string) {
+	ctx := context.Background()
+	config.ConnectDB()
+	migrator, err := migrations.New(config.DB, config.DBDriver, config.Timezone())
+	if err != nil {
+		log.Fatal("加载迁移脚本失败: ", err)
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("已应用迁移 %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("无效的回滚步数: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("已回滚迁移 %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("数据库版本: %d，程序支持的最新版本: %d\n", version, migrator.Latest())
+		for _, status := range statuses {
+			state := "待执行"
+			if status.Applied {
+				state = "已应用"
+			}
+			fmt.Printf("  %04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		log.Fatalf("未知的迁移命令: %s（可用: up, down [步数], status）", command)
+	}
+}
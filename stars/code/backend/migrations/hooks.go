@@ -0,0 +1,110 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Hook 在某个版本的升级脚本之前执行的数据迁移，用于 SQL 无法完成的数据转换
+// SQLite 中与升级脚本在同一事务中执行；MySQL 中作为迁移的第一步单独提交，完成后不会重复执行，
+// 但中途失败时会从头重新执行，因此需要可以重复执行
+type Hook func(ctx context.Context, tx *sql.Tx) error
+
+// hooks 返回指定方言下各版本升级前需要执行的数据迁移，loc 为换算日历日期使用的默认时区
+func hooks(dialect string, loc *time.Location) map[int]Hook {
+	return map[int]Hook{
+		11: ratingDays(dialect, loc),
+	}
+}
+
+// timestampLayouts 旧版本以文本保存的评分时间可能使用的格式
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ratingDays 将每日评分的时间换算为 loc 时区的日历日期，写入临时表 daily_rating_days，
+// 供 0011 迁移脚本合并同一天的评分并改为按日期保存，临时表由迁移脚本删除
+// 临时表已存在时先清空，中途失败后可以重复执行
+// 迁移前尚无用户时区设置，所有评分按默认时区换算
+// MySQL 按会话时区返回 TIMESTAMP 的值，因此读取 UNIX 时间戳，使换算结果不依赖服务器的 time_zone 设置
+func ratingDays(dialect string, loc *time.Location) Hook {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		statements := []string{
+			`CREATE TABLE IF NOT EXISTS daily_rating_days (rating_id INT PRIMARY KEY, day DATE NOT NULL)`,
+			`DELETE FROM daily_rating_days`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+
+		query := `SELECT id, COALESCE(date, created_at) FROM daily_ratings`
+		if dialect == "mysql" {
+			query = `SELECT id, COALESCE(UNIX_TIMESTAMP(date), UNIX_TIMESTAMP(created_at)) FROM daily_ratings`
+		}
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		days := make(map[int]string)
+		for rows.Next() {
+			var id int
+			var value interface{}
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			t, err := parseTimestamp(value)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("评分 %d 的日期无法解析: %w", id, err)
+			}
+			days[id] = t.In(loc).Format("2006-01-02")
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for id, day := range days {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO daily_rating_days (rating_id, day) VALUES (?, ?)`, id, day); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// parseTimestamp 解析驱动返回的时间、UNIX 时间戳或时间文本，没有时区信息的文本按 UTC 处理
+func parseTimestamp(value interface{}) (time.Time, error) {
+	var text string
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case int64:
+		return time.Unix(v, 0), nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return time.Time{}, fmt.Errorf("不支持的类型 %T", value)
+	}
+	if seconds, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("未知的时间格式: %s", text)
+}
@@ -0,0 +1,317 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// files 内嵌的迁移脚本，按方言分目录存放，文件名格式为 <版本号>_<名称>.<up|down>.sql
+//
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// ErrSchemaTooNew 表示数据库的表结构版本高于当前程序支持的版本
+var ErrSchemaTooNew = errors.New("数据库表结构版本高于程序支持的版本")
+
+// Migration 代表一个版本的表结构迁移
+type Migration struct {
+	Version int    // 版本号
+	Name    string // 迁移名称
+	Up      string // 升级脚本
+	Down    string // 回滚脚本
+	Before  Hook   // 升级脚本之前执行的数据迁移（可以为空）
+}
+
+// Status 代表一个迁移的应用状态
+type Status struct {
+	Migration
+	Applied bool // 是否已应用
+}
+
+// Migrator 负责对数据库执行迁移，并在 schema_migrations 表中记录已应用的版本
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	stepwise   bool // DDL 会隐式提交（MySQL），迁移无法整体回滚，需要逐步记录进度
+}
+
+// New 加载指定方言的迁移脚本并创建 Migrator，loc 为数据迁移换算日历日期使用的默认时区
+func New(db *sql.DB, dialect string, loc *time.Location) (*Migrator, error) {
+	migrations, err := load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	before := hooks(dialect, loc)
+	for i := range migrations {
+		migrations[i].Before = before[migrations[i].Version]
+	}
+	return &Migrator{db: db, migrations: migrations, stepwise: dialect == "mysql"}, nil
+}
+
+// Latest 返回程序支持的最新版本号
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 返回数据库当前的表结构版本，未应用任何迁移时为0
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Check 在数据库版本高于程序支持的版本时返回 ErrSchemaTooNew，并返回待应用的迁移数
+func (m *Migrator) Check(ctx context.Context) (int, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if version > m.Latest() {
+		return 0, fmt.Errorf("%w: 数据库版本 %d，程序版本 %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up 按版本顺序应用所有待执行的迁移，返回已应用的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if _, err := m.Check(ctx); err != nil {
+		return nil, err
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			continue
+		}
+		err := m.exec(ctx, migration.Version, "up", migration.Before, migration.Up,
+			`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, migration.Version, migration.Name)
+		if err != nil {
+			return applied, fmt.Errorf("应用迁移 %04d_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down 按版本倒序回滚最近的 steps 个迁移，返回已回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := m.migrations[i]
+		if migration.Version > version {
+			continue
+		}
+		err := m.exec(ctx, migration.Version, "down", nil, migration.Down, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+		if err != nil {
+			return reverted, fmt.Errorf("回滚迁移 %04d_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Status 返回所有迁移及其应用状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{Migration: migration, Applied: migration.Version <= version})
+	}
+	return statuses, nil
+}
+
+// ensureTable 创建记录迁移版本的 schema_migrations 表，逐步执行迁移时同时创建记录进度的 schema_migration_progress 表
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil || !m.stepwise {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migration_progress (
+    version INT NOT NULL,
+    direction VARCHAR(4) NOT NULL,
+    steps INT NOT NULL,
+    PRIMARY KEY (version, direction)
+)`)
+	return err
+}
+
+// exec 执行一个版本的数据迁移（before，可以为空）、迁移脚本和版本记录语句
+// SQLite 的 DDL 支持事务，所有步骤在同一事务中执行，失败时整体回滚；
+// MySQL 的 DDL 会隐式提交，失败时已执行的语句无法回滚，因此每完成一步（数据迁移或一条语句）就记录进度，
+// 再次执行时跳过已完成的步骤，从失败的步骤继续，不会重复执行已生效的 DDL
+func (m *Migrator) exec(ctx context.Context, version int, direction string, before Hook, script string, record string, args ...interface{}) error {
+	var steps []Hook
+	if before != nil {
+		steps = append(steps, before)
+	}
+	for _, statement := range splitStatements(script) {
+		statement := statement
+		steps = append(steps, func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, statement)
+			return err
+		})
+	}
+
+	if !m.stepwise {
+		return m.inTx(ctx, func(tx *sql.Tx) error {
+			for _, step := range steps {
+				if err := step(ctx, tx); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, record, args...)
+			return err
+		})
+	}
+
+	// 读取上次执行中断时已完成的步骤数
+	done := 0
+	query := `SELECT steps FROM schema_migration_progress WHERE version = ? AND direction = ?`
+	if err := m.db.QueryRowContext(ctx, query, version, direction).Scan(&done); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	for i := done; i < len(steps); i++ {
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if err := steps[i](ctx, tx); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migration_progress WHERE version = ? AND direction = ?`, version, direction); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migration_progress (version, direction, steps) VALUES (?, ?, ?)`, version, direction, i+1)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("第 %d 步: %w", i+1, err)
+		}
+	}
+
+	// 记录版本并清除进度
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM schema_migration_progress WHERE version = ?`, version)
+		return err
+	})
+}
+
+// inTx 在事务中执行 fn，fn 返回错误时回滚
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// load 读取并解析指定方言的迁移脚本，按版本号排序
+func load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的迁移方言 %q: %w", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		versionText, migrationName, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if !ok || err != nil || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", name)
+		}
+
+		content, err := files.ReadFile(path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = migration
+		}
+		if direction == ".up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("迁移 %04d_%s 缺少 up 或 down 脚本", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements 按行尾分号拆分脚本中的多条语句，并去掉注释行
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
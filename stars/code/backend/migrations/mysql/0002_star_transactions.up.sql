@@ -0,0 +1,27 @@
+-- 创建只追加的星数流水表
+CREATE TABLE IF NOT EXISTS star_transactions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    goal_id INT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    source_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE CASCADE,
+    INDEX idx_star_transactions_goal (goal_id, id)
+);
+
+-- 将已有的每日评分补录为获得星数的流水
+INSERT INTO star_transactions (goal_id, type, amount, reason, source, source_id, created_at)
+SELECT goal_id, 'earn', rating, '每日评分', 'daily_rating', id, created_at FROM daily_ratings;
+
+-- 目标星数与评分总和不一致的部分（手动修改过的星数）补录为调整流水
+INSERT INTO star_transactions (goal_id, type, amount, reason, source, created_at)
+SELECT g.id, 'adjust', COALESCE(g.stars, 0) - COALESCE(r.total, 0), '迁移前手动修改的星数', 'migration', CURRENT_TIMESTAMP
+FROM star_goals g
+LEFT JOIN (SELECT goal_id, SUM(rating) AS total FROM daily_ratings GROUP BY goal_id) r ON r.goal_id = g.id
+WHERE COALESCE(g.stars, 0) <> COALESCE(r.total, 0);
+
+-- 星数改为由流水汇总得出
+ALTER TABLE star_goals DROP COLUMN stars;
@@ -0,0 +1,6 @@
+-- 删除目标的所属用户
+ALTER TABLE star_goals DROP FOREIGN KEY fk_star_goals_owner;
+
+DROP INDEX idx_star_goals_owner ON star_goals;
+
+ALTER TABLE star_goals DROP COLUMN owner_id;
@@ -0,0 +1,4 @@
+-- 删除全文索引
+ALTER TABLE comments DROP INDEX ft_comments_content;
+
+ALTER TABLE star_goals DROP INDEX ft_star_goals_text;
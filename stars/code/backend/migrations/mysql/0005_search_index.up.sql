@@ -0,0 +1,4 @@
+-- 为目标标题、描述和评论内容建立 ngram 全文索引，中文文本无需空格分词即可检索
+ALTER TABLE star_goals ADD FULLTEXT INDEX ft_star_goals_text (title, description) WITH PARSER ngram;
+
+ALTER TABLE comments ADD FULLTEXT INDEX ft_comments_content (content) WITH PARSER ngram;
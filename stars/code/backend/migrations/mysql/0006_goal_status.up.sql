@@ -0,0 +1,8 @@
+-- 为目标添加状态：active（进行中）、paused（已暂停）、completed（已完成）、archived（已归档）
+ALTER TABLE star_goals ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+ALTER TABLE star_goals ADD COLUMN completed_at TIMESTAMP NULL DEFAULT NULL;
+
+ALTER TABLE star_goals ADD COLUMN archived_at TIMESTAMP NULL DEFAULT NULL;
+
+CREATE INDEX idx_star_goals_owner_status ON star_goals (owner_id, status);
@@ -0,0 +1,6 @@
+-- 删除父目标
+ALTER TABLE star_goals DROP FOREIGN KEY fk_star_goals_parent;
+
+DROP INDEX idx_star_goals_parent ON star_goals;
+
+ALTER TABLE star_goals DROP COLUMN parent_id;
@@ -0,0 +1,4 @@
+-- 删除标签，目标的类别字段保持不变
+DROP TABLE IF EXISTS goal_tags;
+
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,28 @@
+-- 创建标签表，标签名在同一用户内唯一；尚无所属用户的标签由第一个注册的用户认领
+CREATE TABLE IF NOT EXISTS tags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    owner_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_owner_tag (owner_id, name),
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 创建目标与标签的多对多关联表
+CREATE TABLE IF NOT EXISTS goal_tags (
+    goal_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (goal_id, tag_id),
+    INDEX idx_goal_tags_tag (tag_id),
+    FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+-- 将已有的类别迁移为标签
+INSERT INTO tags (owner_id, name, created_at)
+SELECT DISTINCT owner_id, LOWER(TRIM(category)), CURRENT_TIMESTAMP FROM star_goals
+WHERE category IS NOT NULL AND TRIM(category) <> '';
+
+INSERT INTO goal_tags (goal_id, tag_id)
+SELECT g.id, t.id FROM star_goals g
+JOIN tags t ON t.name = LOWER(TRIM(g.category)) AND (t.owner_id = g.owner_id OR (t.owner_id IS NULL AND g.owner_id IS NULL));
@@ -0,0 +1,2 @@
+-- 删除类别表，目标的类别字段保持不变
+DROP TABLE IF EXISTS categories;
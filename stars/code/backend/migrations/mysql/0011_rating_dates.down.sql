@@ -0,0 +1,4 @@
+-- 评分日期恢复为时间戳（当天零点），合并掉的重复评分无法恢复
+ALTER TABLE daily_ratings MODIFY date TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE users DROP COLUMN timezone;
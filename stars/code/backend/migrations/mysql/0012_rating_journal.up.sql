@@ -0,0 +1,4 @@
+-- 为每日评分添加日记：Markdown 格式的备注和心情
+ALTER TABLE daily_ratings ADD COLUMN note TEXT NULL;
+
+ALTER TABLE daily_ratings ADD COLUMN mood VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 删除成就表
+DROP TABLE IF EXISTS achievements;
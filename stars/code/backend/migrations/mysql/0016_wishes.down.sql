@@ -0,0 +1,12 @@
+-- 删除心愿和兑换记录，兑换产生的流水不再属于任何目标，一并删除
+DROP TABLE IF EXISTS wish_redemptions;
+
+DROP TABLE IF EXISTS wishes;
+
+DELETE FROM star_transactions WHERE goal_id IS NULL AND owner_id IS NOT NULL;
+
+ALTER TABLE star_transactions DROP FOREIGN KEY fk_star_transactions_owner;
+
+DROP INDEX idx_star_transactions_owner ON star_transactions;
+
+ALTER TABLE star_transactions DROP COLUMN owner_id;
@@ -0,0 +1,39 @@
+-- 与目标无关的星数流水（如兑换心愿）记录所属用户
+ALTER TABLE star_transactions ADD COLUMN owner_id INT NULL;
+
+ALTER TABLE star_transactions ADD CONSTRAINT fk_star_transactions_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_star_transactions_owner ON star_transactions (owner_id);
+
+-- 创建心愿表，心愿用星数兑换，设置了审批人时兑换需要审批人同意
+CREATE TABLE IF NOT EXISTS wishes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    owner_id INT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+    price INT NOT NULL,
+    approver_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_wishes_owner (owner_id),
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (approver_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- 创建心愿兑换记录表，保存兑换时的心愿标题和价格
+CREATE TABLE IF NOT EXISTS wish_redemptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    wish_id INT NULL,
+    user_id INT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    price INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    approver_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP NULL,
+    INDEX idx_wish_redemptions_user (user_id, id),
+    INDEX idx_wish_redemptions_approver (approver_id, status),
+    FOREIGN KEY (wish_id) REFERENCES wishes(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (approver_id) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,8 @@
+-- 删除评论编辑历史，已删除评论的占位一并删除（其回复随外键级联删除）
+DROP TABLE IF EXISTS comment_edits;
+
+DELETE FROM comments WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE comments DROP COLUMN deleted_at;
+
+ALTER TABLE comments DROP COLUMN edited_at;
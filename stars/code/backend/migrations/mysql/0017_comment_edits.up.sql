@@ -0,0 +1,14 @@
+-- 评论的编辑时间和删除时间，删除有回复的评论时保留为占位（内容清空），使回复仍挂在原处
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMP NULL;
+
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP NULL;
+
+-- 创建评论编辑历史表，每次编辑保存被替换的内容
+CREATE TABLE IF NOT EXISTS comment_edits (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    comment_id INT NOT NULL,
+    content TEXT NOT NULL,
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_comment_edits_comment (comment_id, id),
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+-- 恢复删除目标时级联删除星数流水，已删除目标留下的流水一并删除，关联目标的流水不再记录所属用户
+DELETE FROM star_transactions WHERE goal_id IS NULL AND source <> 'redemption';
+
+UPDATE star_transactions SET owner_id = NULL WHERE goal_id IS NOT NULL;
+
+ALTER TABLE star_transactions DROP FOREIGN KEY fk_star_transactions_goal;
+
+ALTER TABLE star_transactions ADD CONSTRAINT star_transactions_ibfk_1 FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+-- 删除目标时保留其星数流水：goal_id 改为 ON DELETE SET NULL，流水记录所属用户，星数余额不随目标删除而变化
+UPDATE star_transactions t JOIN star_goals g ON g.id = t.goal_id SET t.owner_id = g.owner_id WHERE t.owner_id IS NULL;
+
+-- 0002 创建的外键未命名，由 MySQL 自动命名为 star_transactions_ibfk_1
+ALTER TABLE star_transactions DROP FOREIGN KEY star_transactions_ibfk_1;
+
+ALTER TABLE star_transactions ADD CONSTRAINT fk_star_transactions_goal FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+-- 删除初始表结构
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS daily_ratings;
+DROP TABLE IF EXISTS star_goals;
@@ -0,0 +1,6 @@
+-- 恢复星数列，并以流水汇总值回填
+ALTER TABLE star_goals ADD COLUMN stars INT DEFAULT 0;
+
+UPDATE star_goals SET stars = (SELECT COALESCE(SUM(amount), 0) FROM star_transactions WHERE star_transactions.goal_id = star_goals.id);
+
+DROP TABLE IF EXISTS star_transactions;
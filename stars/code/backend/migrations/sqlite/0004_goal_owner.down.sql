@@ -0,0 +1,4 @@
+-- 删除目标的所属用户
+DROP INDEX IF EXISTS idx_star_goals_owner;
+
+ALTER TABLE star_goals DROP COLUMN owner_id;
@@ -0,0 +1,7 @@
+-- 为目标添加所属用户
+ALTER TABLE star_goals ADD COLUMN owner_id INT NULL REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_star_goals_owner ON star_goals (owner_id);
+
+-- 已有目标归属于最早注册的用户；尚无用户时，由第一个注册的用户认领
+UPDATE star_goals SET owner_id = (SELECT MIN(id) FROM users) WHERE owner_id IS NULL;
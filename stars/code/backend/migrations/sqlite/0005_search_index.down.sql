@@ -0,0 +1 @@
+-- SQLite 没有为搜索建立索引，无需回滚
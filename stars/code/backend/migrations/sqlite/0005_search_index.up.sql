@@ -0,0 +1,2 @@
+-- SQLite 的搜索使用 LIKE 逐个匹配检索词，无需全文索引
+-- 保留此版本使两种方言的迁移版本号保持一致
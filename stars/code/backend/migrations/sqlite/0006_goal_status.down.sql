@@ -0,0 +1,8 @@
+-- 删除目标状态
+DROP INDEX IF EXISTS idx_star_goals_owner_status;
+
+ALTER TABLE star_goals DROP COLUMN archived_at;
+
+ALTER TABLE star_goals DROP COLUMN completed_at;
+
+ALTER TABLE star_goals DROP COLUMN status;
@@ -0,0 +1,6 @@
+-- 删除目标星数和截止日期
+DROP INDEX IF EXISTS idx_star_goals_owner_due;
+
+ALTER TABLE star_goals DROP COLUMN due_date;
+
+ALTER TABLE star_goals DROP COLUMN target_stars;
@@ -0,0 +1,6 @@
+-- 为目标添加目标星数和截止日期
+ALTER TABLE star_goals ADD COLUMN target_stars INT NULL;
+
+ALTER TABLE star_goals ADD COLUMN due_date DATE NULL;
+
+CREATE INDEX IF NOT EXISTS idx_star_goals_owner_due ON star_goals (owner_id, due_date);
@@ -0,0 +1,4 @@
+-- 删除父目标
+DROP INDEX IF EXISTS idx_star_goals_parent;
+
+ALTER TABLE star_goals DROP COLUMN parent_id;
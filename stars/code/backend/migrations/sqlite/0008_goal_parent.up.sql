@@ -0,0 +1,4 @@
+-- 为目标添加父目标，用于拆分子目标；删除父目标时由程序决定级联删除还是转移子目标，外键兜底置空
+ALTER TABLE star_goals ADD COLUMN parent_id INT NULL REFERENCES star_goals(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_star_goals_parent ON star_goals (parent_id);
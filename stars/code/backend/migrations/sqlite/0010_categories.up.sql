@@ -0,0 +1,21 @@
+-- 创建类别表，类别名在同一用户内唯一；目标仍通过 category 字段按名称归入类别
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(20) NOT NULL DEFAULT '',
+    icon VARCHAR(50) NOT NULL DEFAULT '',
+    sort_order INT NOT NULL DEFAULT 0,
+    weekly_star_budget INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (owner_id, name),
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 规范化已有目标的类别名，并为每个用户用到的类别创建类别记录
+UPDATE star_goals SET category = TRIM(category) WHERE category IS NOT NULL;
+
+INSERT INTO categories (owner_id, name, created_at, updated_at)
+SELECT DISTINCT owner_id, category, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM star_goals
+WHERE category IS NOT NULL AND category <> '';
@@ -0,0 +1,19 @@
+-- 评分日期恢复为时间戳（当天零点），合并掉的重复评分无法恢复
+CREATE TABLE daily_ratings_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    goal_id INT NOT NULL,
+    rating INT NOT NULL CHECK (rating >= 1 AND rating <= 5),
+    date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE CASCADE,
+    UNIQUE (goal_id, date)
+);
+
+INSERT INTO daily_ratings_old (id, goal_id, rating, date, created_at)
+SELECT id, goal_id, rating, date || ' 00:00:00', created_at FROM daily_ratings;
+
+DROP TABLE daily_ratings;
+
+ALTER TABLE daily_ratings_old RENAME TO daily_ratings;
+
+ALTER TABLE users DROP COLUMN timezone;
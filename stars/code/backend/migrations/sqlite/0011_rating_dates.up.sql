@@ -0,0 +1,36 @@
+-- 用户可以设置自己的时区（IANA 时区名），为空时使用服务配置的默认时区
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
+
+-- 每日评分改为按日历日期保存，执行本脚本前程序已将评分时间按默认时区换算为日期，写入临时表 daily_rating_days
+-- 同一目标同一天有多条评分时只保留最新的一条，其余评分获得的星数以调整流水冲回
+INSERT INTO star_transactions (goal_id, type, amount, reason, source, source_id, created_at)
+SELECT r.goal_id, 'adjust', -r.rating, '合并同一天的重复评分', 'daily_rating', r.id, CURRENT_TIMESTAMP
+FROM daily_ratings r JOIN daily_rating_days d ON d.rating_id = r.id
+WHERE EXISTS (
+    SELECT 1 FROM daily_ratings r2 JOIN daily_rating_days d2 ON d2.rating_id = r2.id
+    WHERE r2.goal_id = r.goal_id AND d2.day = d.day AND r2.id > r.id
+);
+
+CREATE TABLE daily_ratings_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    goal_id INT NOT NULL,
+    rating INT NOT NULL CHECK (rating >= 1 AND rating <= 5),
+    date DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE CASCADE,
+    UNIQUE (goal_id, date)
+);
+
+INSERT INTO daily_ratings_new (id, goal_id, rating, date, created_at)
+SELECT r.id, r.goal_id, r.rating, d.day, r.created_at
+FROM daily_ratings r JOIN daily_rating_days d ON d.rating_id = r.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM daily_ratings r2 JOIN daily_rating_days d2 ON d2.rating_id = r2.id
+    WHERE r2.goal_id = r.goal_id AND d2.day = d.day AND r2.id > r.id
+);
+
+DROP TABLE daily_ratings;
+
+ALTER TABLE daily_ratings_new RENAME TO daily_ratings;
+
+DROP TABLE daily_rating_days;
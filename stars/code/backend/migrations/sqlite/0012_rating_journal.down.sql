@@ -0,0 +1,4 @@
+-- 删除每日评分的备注和心情
+ALTER TABLE daily_ratings DROP COLUMN mood;
+
+ALTER TABLE daily_ratings DROP COLUMN note;
@@ -0,0 +1,2 @@
+-- 删除目标的评分计划
+ALTER TABLE star_goals DROP COLUMN schedule;
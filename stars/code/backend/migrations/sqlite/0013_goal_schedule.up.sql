@@ -0,0 +1,2 @@
+-- 为目标添加评分计划：daily（每天）、weekdays:1,3,5（每周固定几天）或 weekly:3（每周任意几天），已有目标为每天
+ALTER TABLE star_goals ADD COLUMN schedule VARCHAR(50) NOT NULL DEFAULT 'daily';
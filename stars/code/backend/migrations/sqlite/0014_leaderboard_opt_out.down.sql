@@ -0,0 +1,2 @@
+-- 删除排行榜设置
+ALTER TABLE users DROP COLUMN leaderboard_opt_out;
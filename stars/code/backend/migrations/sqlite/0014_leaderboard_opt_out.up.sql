@@ -0,0 +1,2 @@
+-- 用户可以选择不参加排行榜
+ALTER TABLE users ADD COLUMN leaderboard_opt_out BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+-- 创建成就表，每个用户的每个成就只获得一次
+CREATE TABLE IF NOT EXISTS achievements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    badge VARCHAR(50) NOT NULL,
+    earned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, badge),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,40 @@
+-- 与目标无关的星数流水（如兑换心愿）记录所属用户
+ALTER TABLE star_transactions ADD COLUMN owner_id INT NULL REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_star_transactions_owner ON star_transactions (owner_id);
+
+-- 创建心愿表，心愿用星数兑换，设置了审批人时兑换需要审批人同意
+CREATE TABLE IF NOT EXISTS wishes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id INT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+    price INT NOT NULL,
+    approver_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (approver_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishes_owner ON wishes (owner_id);
+
+-- 创建心愿兑换记录表，保存兑换时的心愿标题和价格
+CREATE TABLE IF NOT EXISTS wish_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    wish_id INT NULL,
+    user_id INT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    price INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    approver_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP NULL,
+    FOREIGN KEY (wish_id) REFERENCES wishes(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (approver_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_wish_redemptions_user ON wish_redemptions (user_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_wish_redemptions_approver ON wish_redemptions (approver_id, status);
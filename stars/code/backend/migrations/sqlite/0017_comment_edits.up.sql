@@ -0,0 +1,15 @@
+-- 评论的编辑时间和删除时间，删除有回复的评论时保留为占位（内容清空），使回复仍挂在原处
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMP NULL;
+
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP NULL;
+
+-- 创建评论编辑历史表，每次编辑保存被替换的内容
+CREATE TABLE IF NOT EXISTS comment_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INT NOT NULL,
+    content TEXT NOT NULL,
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_edits_comment ON comment_edits (comment_id, id);
@@ -0,0 +1,25 @@
+-- 恢复删除目标时级联删除星数流水，已删除目标留下的流水一并删除，关联目标的流水不再记录所属用户
+CREATE TABLE star_transactions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    goal_id INT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    source_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    owner_id INT NULL REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE CASCADE
+);
+
+INSERT INTO star_transactions_old (id, goal_id, type, amount, reason, source, source_id, created_at, owner_id)
+SELECT id, goal_id, type, amount, reason, source, source_id, created_at, CASE WHEN goal_id IS NULL THEN owner_id END
+FROM star_transactions WHERE goal_id IS NOT NULL OR source = 'redemption';
+
+DROP TABLE star_transactions;
+
+ALTER TABLE star_transactions_old RENAME TO star_transactions;
+
+CREATE INDEX IF NOT EXISTS idx_star_transactions_goal ON star_transactions (goal_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_star_transactions_owner ON star_transactions (owner_id);
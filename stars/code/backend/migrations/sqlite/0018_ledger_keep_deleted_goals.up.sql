@@ -0,0 +1,26 @@
+-- 删除目标时保留其星数流水：goal_id 改为 ON DELETE SET NULL，流水记录所属用户，星数余额不随目标删除而变化
+-- SQLite 不能修改外键，重建星数流水表，关联目标的流水补录目标的所属用户
+CREATE TABLE star_transactions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    goal_id INT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    source_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    owner_id INT NULL REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (goal_id) REFERENCES star_goals(id) ON DELETE SET NULL
+);
+
+INSERT INTO star_transactions_new (id, goal_id, type, amount, reason, source, source_id, created_at, owner_id)
+SELECT t.id, t.goal_id, t.type, t.amount, t.reason, t.source, t.source_id, t.created_at, COALESCE(t.owner_id, g.owner_id)
+FROM star_transactions t LEFT JOIN star_goals g ON g.id = t.goal_id;
+
+DROP TABLE star_transactions;
+
+ALTER TABLE star_transactions_new RENAME TO star_transactions;
+
+CREATE INDEX IF NOT EXISTS idx_star_transactions_goal ON star_transactions (goal_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_star_transactions_owner ON star_transactions (owner_id);
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// Achievement 用户获得的一个成就徽章
+type Achievement struct {
+	ID       int       `json:"id" db:"id"`               // 成就记录ID
+	UserID   int       `json:"user_id" db:"user_id"`     // 用户ID
+	Badge    string    `json:"badge" db:"badge"`         // 徽章标识，对应成就规则的 Key
+	EarnedAt time.Time `json:"earned_at" db:"earned_at"` // 获得时间
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Category 代表用户管理的一个目标类别，目标通过 category 字段按名称归入类别
+type Category struct {
+	ID               int                `json:"id" db:"id"`                                 // 类别ID
+	OwnerID          int                `json:"owner_id" db:"owner_id"`                     // 所属用户ID
+	Name             string             `json:"name" db:"name"`                             // 类别名（同一用户内唯一）
+	Color            string             `json:"color" db:"color"`                           // 显示颜色，#RRGGBB 格式（可以为空）
+	Icon             string             `json:"icon" db:"icon"`                             // 图标名或 emoji（可以为空）
+	SortOrder        int                `json:"sort_order" db:"sort_order"`                 // 排序值，越小越靠前
+	WeeklyStarBudget *int               `json:"weekly_star_budget" db:"weekly_star_budget"` // 每周星数预算（可以为空）
+	GoalCount        int                `json:"goal_count" db:"-"`                          // 该类别下的目标数，查询类别列表时计算
+	TotalStars       int                `json:"total_stars" db:"-"`                         // 该类别下所有目标的星数合计
+	WeekStars        int                `json:"week_stars" db:"-"`                          // 最近7天评分获得的星数合计
+	Activity         []CategoryActivity `json:"activity,omitempty" db:"-"`                  // 最近7天每天的评分情况，按日期升序
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`                 // 创建时间
+	UpdatedAt        time.Time          `json:"updated_at" db:"updated_at"`                 // 更新时间
+}
+
+// CategoryActivity 类别某一天的评分情况
+type CategoryActivity struct {
+	Date    Date `json:"date"`    // 日期
+	Ratings int  `json:"ratings"` // 评分次数
+	Stars   int  `json:"stars"`   // 评分星数合计
+}
+
+// NormalizeCategoryName 去掉类别名首尾空白并合并连续空白，保留大小写用于显示
+func NormalizeCategoryName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// FillActivity 将评分情况补齐为从 from 开始连续 days 天，没有评分的日期记为0，并汇总 WeekStars
+func (c *Category) FillActivity(from Date, days int) {
+	byDate := make(map[string]CategoryActivity, len(c.Activity))
+	for _, activity := range c.Activity {
+		byDate[activity.Date.String()] = activity
+	}
+
+	c.Activity = make([]CategoryActivity, days)
+	c.WeekStars = 0
+	for i := range c.Activity {
+		date := Date{from.AddDate(0, 0, i)}
+		activity, ok := byDate[date.String()]
+		if !ok {
+			activity = CategoryActivity{Date: date}
+		}
+		c.Activity[i] = activity
+		c.WeekStars += activity.Stars
+	}
+}
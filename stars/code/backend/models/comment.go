@@ -6,9 +6,19 @@ import (
 
 // Comment 代表一个评论
 type Comment struct {
-    ID        int       `json:"id" db:"id"`                 // 评论ID
-    GoalID    int       `json:"goal_id" db:"goal_id"`       // 关联的目标ID
-    ParentID  *int      `json:"parent_id" db:"parent_id"`   // 父评论ID（用于回复评论，可以为空）
-    Content   string    `json:"content" db:"content"`       // 评论内容
-    CreatedAt time.Time `json:"created_at" db:"created_at"` // 创建时间
-}
\ No newline at end of file
+    ID        int        `json:"id" db:"id"`                 // 评论ID
+    GoalID    int        `json:"goal_id" db:"goal_id"`       // 关联的目标ID
+    ParentID  *int       `json:"parent_id" db:"parent_id"`   // 父评论ID（用于回复评论，可以为空）
+    Content   string     `json:"content" db:"content"`       // 评论内容（已删除的评论为空）
+    CreatedAt time.Time  `json:"created_at" db:"created_at"` // 创建时间
+    EditedAt  *time.Time `json:"edited_at" db:"edited_at"`   // 最后一次编辑的时间（未编辑过为空）
+    DeletedAt *time.Time `json:"deleted_at" db:"deleted_at"` // 删除时间，有回复的评论删除后保留为占位
+}
+
+// CommentEdit 评论的一次编辑，保存被替换的内容
+type CommentEdit struct {
+    ID        int       `json:"id" db:"id"`                 // 编辑记录ID
+    CommentID int       `json:"comment_id" db:"comment_id"` // 评论ID
+    Content   string    `json:"content" db:"content"`       // 编辑前的内容
+    EditedAt  time.Time `json:"edited_at" db:"edited_at"`   // 编辑时间
+}
@@ -4,11 +4,48 @@ import (
 	"time"
 )
 
+// 每日评分的心情
+const (
+	MoodGreat = "great" // 很好
+	MoodGood  = "good"  // 不错
+	MoodOkay  = "okay"  // 一般
+	MoodBad   = "bad"   // 不好
+	MoodAwful = "awful" // 很差
+)
+
 // DailyRating 代表目标的每日评分记录
 type DailyRating struct {
 	ID        int       `json:"id" db:"id"`                 // 记录ID
 	GoalID    int       `json:"goal_id" db:"goal_id"`       // 目标ID
 	Rating    int       `json:"rating" db:"rating"`         // 评分 (1-5星)
-	Date      time.Time `json:"date" db:"date"`             // 评分日期
+	Date      Date      `json:"date" db:"date"`             // 评分的日历日期（按用户时区）
+	Note      string    `json:"note" db:"note"`             // 日记备注（Markdown），可以为空
+	Mood      string    `json:"mood" db:"mood"`             // 心情：great、good、okay、bad、awful，可以为空
 	CreatedAt time.Time `json:"created_at" db:"created_at"` // 创建时间
 }
+
+// RatingTotal 一组评分的次数和星数合计
+type RatingTotal struct {
+	Count int `json:"count"` // 评分次数
+	Stars int `json:"stars"` // 评分星数合计
+}
+
+// JournalEntry 代表日记中的一条记录，即一条带备注的每日评分及其所属目标
+type JournalEntry struct {
+	RatingID  int    `json:"rating_id"`  // 每日评分ID
+	GoalID    int    `json:"goal_id"`    // 目标ID
+	GoalTitle string `json:"goal_title"` // 目标标题
+	Date      Date   `json:"date"`       // 评分日期
+	Rating    int    `json:"rating"`     // 评分 (1-5星)
+	Mood      string `json:"mood"`       // 心情
+	Note      string `json:"note"`       // 日记备注（Markdown）
+}
+
+// ValidMood 判断心情是否合法，空字符串表示未填写
+func ValidMood(mood string) bool {
+	switch mood {
+	case "", MoodGreat, MoodGood, MoodOkay, MoodBad, MoodAwful:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout 日历日期在 JSON 和数据库中的格式
+const DateLayout = "2006-01-02"
+
+// Date 表示不含时间的日历日期，JSON 中以 "YYYY-MM-DD" 表示，内部保存为当天零点（UTC）
+type Date struct {
+	time.Time
+}
+
+// DateOf 返回时间 t 在其所在时区对应的日历日期
+func DateOf(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate 解析 "YYYY-MM-DD" 格式的日期
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return Date{}, fmt.Errorf("日期格式应为 YYYY-MM-DD: %s", value)
+	}
+	return Date{t}, nil
+}
+
+// String 返回 "YYYY-MM-DD" 格式的日期
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+// DaysUntil 返回从 d 到 other 相隔的天数，other 在 d 之前时为负数
+func (d Date) DaysUntil(other Date) int {
+	return int(other.Sub(d.Time).Hours() / 24)
+}
+
+// MarshalJSON 将日期编码为 "YYYY-MM-DD"
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON 解析 "YYYY-MM-DD" 格式的日期
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("日期应为字符串: %w", err)
+	}
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value 实现 driver.Valuer，以 "YYYY-MM-DD" 文本写入数据库
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan 实现 sql.Scanner，支持驱动返回的时间和文本两种形式
+func (d *Date) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case time.Time:
+		*d = DateOf(value)
+		return nil
+	case string:
+		return d.scanText(value)
+	case []byte:
+		return d.scanText(string(value))
+	}
+	return fmt.Errorf("无法将 %T 转换为日期", src)
+}
+
+// scanText 解析数据库中以文本保存的日期，兼容带时间的格式
+func (d *Date) scanText(value string) error {
+	if len(value) < len(DateLayout) {
+		return fmt.Errorf("无效的日期: %s", value)
+	}
+	parsed, err := ParseDate(value[:len(DateLayout)])
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
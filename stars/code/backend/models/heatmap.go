@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// heatmapLevels 热力图的强度级别数（不含表示没有评分的0级）
+const heatmapLevels = 4
+
+// DayTotal 某天所有评分的次数和星数合计
+type DayTotal struct {
+	Date  Date `json:"date"`  // 日期
+	Count int  `json:"count"` // 评分次数
+	Stars int  `json:"stars"` // 评分星数合计
+}
+
+// Heatmap 一年中每天评分强度的日历热力图，数组按日期顺序从1月1日开始，每天一项
+type Heatmap struct {
+	Year         int   `json:"year"`          // 年份
+	Start        Date  `json:"start"`         // 第一天（1月1日）
+	FirstWeekday int   `json:"first_weekday"` // 第一天是星期几，1 为周一，7 为周日，用于按周排列网格
+	Days         int   `json:"days"`          // 天数
+	Total        int   `json:"total"`         // 全年评分星数合计
+	Max          int   `json:"max"`           // 单日评分星数的最大值
+	Levels       []int `json:"levels"`        // 每天的强度级别：0 为没有评分，1-4 按当天星数占最大值的比例划分
+	Stars        []int `json:"stars"`         // 每天的评分星数合计
+}
+
+// BuildHeatmap 根据按日期汇总的评分生成 year 年的热力图
+func BuildHeatmap(year int, totals []DayTotal) Heatmap {
+	start := Date{time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	days := start.DaysUntil(Date{time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)})
+	heatmap := Heatmap{
+		Year:         year,
+		Start:        start,
+		FirstWeekday: isoWeekday(start),
+		Days:         days,
+		Levels:       make([]int, days),
+		Stars:        make([]int, days),
+	}
+	for _, total := range totals {
+		day := start.DaysUntil(total.Date)
+		if day < 0 || day >= days {
+			continue
+		}
+		heatmap.Stars[day] += total.Stars
+		heatmap.Total += total.Stars
+	}
+	for _, stars := range heatmap.Stars {
+		heatmap.Max = max(heatmap.Max, stars)
+	}
+	for day, stars := range heatmap.Stars {
+		if stars > 0 {
+			heatmap.Levels[day] = (stars*heatmapLevels + heatmap.Max - 1) / heatmap.Max
+		}
+	}
+	return heatmap
+}
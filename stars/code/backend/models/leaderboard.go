@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// 排行榜的排名对象
+const (
+	LeaderboardUsers      = "users"      // 按用户排名
+	LeaderboardGoals      = "goals"      // 按目标排名
+	LeaderboardCategories = "categories" // 按用户的目标类别排名
+)
+
+// 排行榜的统计周期
+const (
+	PeriodWeek  = "week"  // 本周（周一开始）
+	PeriodMonth = "month" // 本月
+	PeriodAll   = "all"   // 全部
+)
+
+// LeaderboardEntry 排行榜中的一项，星数为统计周期内评分星数的合计
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`               // 名次，星数相同时名次相同
+	UserID   int    `json:"user_id"`            // 用户ID
+	Username string `json:"username"`           // 用户名
+	GoalID   int    `json:"goal_id,omitempty"`  // 目标ID，按目标排名时有效
+	Title    string `json:"title,omitempty"`    // 目标标题，按目标排名时有效
+	Category string `json:"category,omitempty"` // 类别，按类别排名时有效
+	Stars    int    `json:"stars"`              // 评分星数合计
+	Ratings  int    `json:"ratings"`            // 评分次数
+}
+
+// ValidLeaderboard 判断排名对象是否有效
+func ValidLeaderboard(by string) bool {
+	return by == LeaderboardUsers || by == LeaderboardGoals || by == LeaderboardCategories
+}
+
+// PeriodStart 返回 today 所在统计周期的起始日期，全部时返回空
+func PeriodStart(period string, today Date) (*Date, error) {
+	switch period {
+	case PeriodWeek:
+		start := bucketStart(today, GranularityWeek)
+		return &start, nil
+	case PeriodMonth:
+		start := bucketStart(today, GranularityMonth)
+		return &start, nil
+	case PeriodAll:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("无效的统计周期: %s", period)
+}
+
+// RankLeaderboard 按星数从多到少排序并计算名次，星数相同时按用户、目标和类别排列，返回前 limit 项（limit 为0时不限制）
+func RankLeaderboard(entries []LeaderboardEntry, limit int) []LeaderboardEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Stars != b.Stars {
+			return a.Stars > b.Stars
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		if a.GoalID != b.GoalID {
+			return a.GoalID < b.GoalID
+		}
+		return a.Category < b.Category
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+		if i > 0 && entries[i].Stars == entries[i-1].Stars {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
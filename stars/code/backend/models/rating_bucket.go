@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+)
+
+// 评分汇总的时间粒度
+const (
+	GranularityDay   = "day"   // 按天
+	GranularityWeek  = "week"  // 按周，每周从周一开始
+	GranularityMonth = "month" // 按自然月
+)
+
+// RatingBucket 一段时间内评分的汇总，没有评分的时间段 Count 为0、Average 为空
+type RatingBucket struct {
+	Start   Date     `json:"start"`   // 起始日期（含），第一个时间段从查询起始日期开始
+	End     Date     `json:"end"`     // 结束日期（含），最后一个时间段到查询结束日期为止
+	Count   int      `json:"count"`   // 评分次数
+	Sum     int      `json:"sum"`     // 评分星数合计
+	Average *float64 `json:"average"` // 平均评分，保留两位小数
+}
+
+// ValidGranularity 判断时间粒度是否有效
+func ValidGranularity(granularity string) bool {
+	switch granularity {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// WeekStart 返回日期 d 所在周的周一
+func WeekStart(d Date) Date {
+	return bucketStart(d, GranularityWeek)
+}
+
+// bucketStart 返回日期 d 所在时间段的起始日期
+func bucketStart(d Date, granularity string) Date {
+	switch granularity {
+	case GranularityWeek:
+		// time.Weekday 以周日为0，换算为距周一的天数
+		offset := (int(d.Weekday()) + 6) % 7
+		return Date{d.AddDate(0, 0, -offset)}
+	case GranularityMonth:
+		return Date{time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)}
+	}
+	return d
+}
+
+// nextBucket 返回 start 所在时间段的下一个时间段的起始日期
+func nextBucket(start Date, granularity string) Date {
+	switch granularity {
+	case GranularityWeek:
+		return Date{start.AddDate(0, 0, 7)}
+	case GranularityMonth:
+		return Date{start.AddDate(0, 1, 0)}
+	}
+	return Date{start.AddDate(0, 0, 1)}
+}
+
+// CountBuckets 返回 from 到 to（含）之间按粒度划分的时间段数
+func CountBuckets(from, to Date, granularity string) int {
+	if from.After(to.Time) {
+		return 0
+	}
+	switch granularity {
+	case GranularityWeek:
+		return bucketStart(from, granularity).DaysUntil(bucketStart(to, granularity))/7 + 1
+	case GranularityMonth:
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+	return from.DaysUntil(to) + 1
+}
+
+// BucketRatings 将 from 到 to（含）之间的评分按粒度汇总，返回连续的时间段，没有评分的时间段也会返回
+func BucketRatings(ratings []DailyRating, from, to Date, granularity string) []RatingBucket {
+	buckets := []RatingBucket{}
+	index := make(map[string]int)
+	for start := bucketStart(from, granularity); !start.After(to.Time); start = nextBucket(start, granularity) {
+		bucket := RatingBucket{Start: start, End: Date{nextBucket(start, granularity).AddDate(0, 0, -1)}}
+		if bucket.Start.Before(from.Time) {
+			bucket.Start = from
+		}
+		if bucket.End.After(to.Time) {
+			bucket.End = to
+		}
+		index[start.String()] = len(buckets)
+		buckets = append(buckets, bucket)
+	}
+
+	for _, rating := range ratings {
+		date := rating.Date
+		if date.Before(from.Time) || date.After(to.Time) {
+			continue
+		}
+		bucket := &buckets[index[bucketStart(date, granularity).String()]]
+		bucket.Count++
+		bucket.Sum += rating.Rating
+	}
+	for i := range buckets {
+		if buckets[i].Count > 0 {
+			average := roundAverage(buckets[i].Sum, buckets[i].Count)
+			buckets[i].Average = &average
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,316 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 目标计划类型
+const (
+	ScheduleDaily    = "daily"    // 每天
+	ScheduleWeekdays = "weekdays" // 每周固定的几天
+	ScheduleWeekly   = "weekly"   // 每周任意几天
+)
+
+// Schedule 目标的评分计划，数据库中以文本保存：daily、weekdays:1,3,5 或 weekly:3
+type Schedule struct {
+	Type  string `json:"type"`            // 计划类型：daily、weekdays 或 weekly
+	Days  []int  `json:"days,omitempty"`  // weekdays 计划的星期几，1 为周一，7 为周日
+	Times int    `json:"times,omitempty"` // weekly 计划每周需要评分的天数
+}
+
+// Normalize 校验计划并规范化：未指定类型时为每天，星期几去重排序，清除与类型无关的字段
+func (s *Schedule) Normalize() error {
+	switch s.Type {
+	case "", ScheduleDaily:
+		*s = Schedule{Type: ScheduleDaily}
+	case ScheduleWeekdays:
+		seen := make(map[int]bool)
+		days := []int{}
+		for _, day := range s.Days {
+			if day < 1 || day > 7 {
+				return fmt.Errorf("星期几必须在1-7之间: %d", day)
+			}
+			if !seen[day] {
+				seen[day] = true
+				days = append(days, day)
+			}
+		}
+		if len(days) == 0 {
+			return errors.New("weekdays 计划至少需要指定一天")
+		}
+		sort.Ints(days)
+		*s = Schedule{Type: ScheduleWeekdays, Days: days}
+	case ScheduleWeekly:
+		if s.Times < 1 || s.Times > 7 {
+			return errors.New("weekly 计划每周的天数必须在1-7之间")
+		}
+		*s = Schedule{Type: ScheduleWeekly, Times: s.Times}
+	default:
+		return fmt.Errorf("无效的计划类型: %s", s.Type)
+	}
+	return nil
+}
+
+// String 返回计划的文本形式
+func (s Schedule) String() string {
+	switch s.Type {
+	case ScheduleWeekdays:
+		days := make([]string, len(s.Days))
+		for i, day := range s.Days {
+			days[i] = strconv.Itoa(day)
+		}
+		return ScheduleWeekdays + ":" + strings.Join(days, ",")
+	case ScheduleWeekly:
+		return ScheduleWeekly + ":" + strconv.Itoa(s.Times)
+	}
+	return ScheduleDaily
+}
+
+// ParseSchedule 解析计划的文本形式
+func ParseSchedule(text string) (Schedule, error) {
+	kind, value, _ := strings.Cut(text, ":")
+	schedule := Schedule{Type: kind}
+	switch kind {
+	case ScheduleWeekdays:
+		for _, day := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(day)
+			if err != nil {
+				return Schedule{}, fmt.Errorf("无效的计划: %s", text)
+			}
+			schedule.Days = append(schedule.Days, n)
+		}
+	case ScheduleWeekly:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("无效的计划: %s", text)
+		}
+		schedule.Times = n
+	}
+	if err := schedule.Normalize(); err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// Value 实现 driver.Valuer，以文本形式写入数据库
+func (s Schedule) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan 实现 sql.Scanner
+func (s *Schedule) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*s = Schedule{Type: ScheduleDaily}
+		return nil
+	case string:
+		schedule, err := ParseSchedule(value)
+		*s = schedule
+		return err
+	case []byte:
+		schedule, err := ParseSchedule(string(value))
+		*s = schedule
+		return err
+	}
+	return fmt.Errorf("无法将 %T 转换为计划", src)
+}
+
+// Scheduled 判断某天是否为计划内的日期，weekly 计划的每一天都可以评分
+func (s Schedule) Scheduled(date Date) bool {
+	if s.Type != ScheduleWeekdays {
+		return true
+	}
+	weekday := isoWeekday(date)
+	for _, day := range s.Days {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Due 判断 today 是否需要评分：今天是计划内的日期且尚未评分，weekly 计划在本周评分天数不足时需要评分
+// rated 为按日期升序排列的评分日期
+func (s Schedule) Due(rated []Date, today Date) bool {
+	if containsDate(rated, today) || !s.Scheduled(today) {
+		return false
+	}
+	if s.Type == ScheduleWeekly {
+		return countRange(rated, bucketStart(today, GranularityWeek), today) < s.Times
+	}
+	return true
+}
+
+// Streaks 根据按日期升序排列的达标评分日期计算连续区间
+// daily 和 weekdays 计划中相邻的计划日期都有评分即为连续，非计划日期不中断也不计入；
+// weekly 计划以周为单位，连续每周评分天数达到要求即为连续，天数为这些周内的评分天数
+func (s Schedule) Streaks(dates []Date) []Streak {
+	if s.Type == ScheduleWeekly {
+		return s.weeklyStreaks(dates)
+	}
+	streaks := []Streak{}
+	for _, date := range dates {
+		if !s.Scheduled(date) {
+			continue
+		}
+		last := len(streaks) - 1
+		if last >= 0 {
+			if streaks[last].End.Equal(date.Time) {
+				continue
+			}
+			if s.next(streaks[last].End).Equal(date.Time) {
+				streaks[last].End = date
+				streaks[last].Days++
+				continue
+			}
+		}
+		streaks = append(streaks, Streak{Start: date, End: date, Days: 1})
+	}
+	return streaks
+}
+
+// weeklyStreaks 计算 weekly 计划的连续区间
+func (s Schedule) weeklyStreaks(dates []Date) []Streak {
+	streaks := []Streak{}
+	var week []Date
+	flush := func() {
+		if len(week) < s.Times {
+			return
+		}
+		last := len(streaks) - 1
+		start := bucketStart(week[0], GranularityWeek)
+		if last >= 0 && bucketStart(streaks[last].End, GranularityWeek).AddDate(0, 0, 7).Equal(start.Time) {
+			streaks[last].End = week[len(week)-1]
+			streaks[last].Days += len(week)
+			return
+		}
+		streaks = append(streaks, Streak{Start: week[0], End: week[len(week)-1], Days: len(week)})
+	}
+	for _, date := range dates {
+		if len(week) > 0 {
+			if week[len(week)-1].Equal(date.Time) {
+				continue
+			}
+			if !bucketStart(week[0], GranularityWeek).Equal(bucketStart(date, GranularityWeek).Time) {
+				flush()
+				week = nil
+			}
+		}
+		week = append(week, date)
+	}
+	flush()
+	return streaks
+}
+
+// CurrentStreak 返回截至 today 仍在延续的连续天数
+// 最后一段之后到今天之前没有漏掉计划日期时仍算延续（今天还可以评分）；weekly 计划中最后一段在本周或上周结束时仍算延续
+func (s Schedule) CurrentStreak(streaks []Streak, today Date) int {
+	if len(streaks) == 0 {
+		return 0
+	}
+	last := streaks[len(streaks)-1]
+	if last.End.After(today.Time) {
+		return 0
+	}
+	if s.Type == ScheduleWeekly {
+		if bucketStart(last.End, GranularityWeek).AddDate(0, 0, 7).Before(bucketStart(today, GranularityWeek).Time) {
+			return 0
+		}
+		return last.Days
+	}
+	if s.next(last.End).Before(today.Time) {
+		return 0
+	}
+	return last.Days
+}
+
+// Compliance 计算从 start 到今天的计划完成率（百分比），即有评分的计划日期数除以计划日期数
+// 今天尚未评分时只统计到昨天；weekly 计划每周计 times 个计划日期，第一周按可评分的天数折算，本周只计已评分的天数
+// 还没有计划日期时返回空
+func (s Schedule) Compliance(rated []Date, start, today Date) *float64 {
+	end := today
+	if !containsDate(rated, today) {
+		end = Date{today.AddDate(0, 0, -1)}
+	}
+	if end.Before(start.Time) {
+		return nil
+	}
+
+	scheduled, hit := 0, 0
+	if s.Type == ScheduleWeekly {
+		currentWeek := bucketStart(today, GranularityWeek)
+		for week := bucketStart(start, GranularityWeek); !week.After(end.Time); week = (Date{week.AddDate(0, 0, 7)}) {
+			from, to := week, Date{week.AddDate(0, 0, 6)}
+			if from.Before(start.Time) {
+				from = start
+			}
+			if to.After(end.Time) {
+				to = end
+			}
+			count := countRange(rated, from, to)
+			slots := min(s.Times, from.DaysUntil(to)+1)
+			if week.Equal(currentWeek.Time) {
+				slots = min(s.Times, count)
+			}
+			scheduled += slots
+			hit += min(count, slots)
+		}
+	} else {
+		for date := start; !date.After(end.Time); date = (Date{date.AddDate(0, 0, 1)}) {
+			if s.Scheduled(date) {
+				scheduled++
+				if containsDate(rated, date) {
+					hit++
+				}
+			}
+		}
+	}
+	if scheduled == 0 {
+		return nil
+	}
+	rate := math.Round(float64(hit)*1000/float64(scheduled)) / 10
+	return &rate
+}
+
+// next 返回 date 之后的第一个计划日期
+func (s Schedule) next(date Date) Date {
+	for i := 1; i <= 7; i++ {
+		candidate := Date{date.AddDate(0, 0, i)}
+		if s.Scheduled(candidate) {
+			return candidate
+		}
+	}
+	return Date{date.AddDate(0, 0, 1)}
+}
+
+// isoWeekday 返回日期是星期几，1 为周一，7 为周日
+func isoWeekday(date Date) int {
+	return (int(date.Weekday())+6)%7 + 1
+}
+
+// containsDate 判断按升序排列的日期中是否包含 date
+func containsDate(dates []Date, date Date) bool {
+	i := sort.Search(len(dates), func(i int) bool { return !dates[i].Before(date.Time) })
+	return i < len(dates) && dates[i].Equal(date.Time)
+}
+
+// countRange 返回按升序排列的日期中 from 到 to（含）之间不同日期的个数
+func countRange(dates []Date, from, to Date) int {
+	count := 0
+	var last time.Time
+	for _, date := range dates {
+		if date.Before(from.Time) || date.After(to.Time) || date.Equal(last) {
+			continue
+		}
+		last = date.Time
+		count++
+	}
+	return count
+}
@@ -1,16 +1,108 @@
 package models
 
 import (
+    "math"
     "time"
 )
 
+// 目标状态
+const (
+    GoalStatusActive    = "active"    // 进行中，可以评分
+    GoalStatusPaused    = "paused"    // 已暂停
+    GoalStatusCompleted = "completed" // 已完成
+    GoalStatusArchived  = "archived"  // 已归档，默认不在目标列表中显示
+)
+
+// goalTransitions 每个状态允许变更到的状态
+var goalTransitions = map[string][]string{
+    GoalStatusActive:    {GoalStatusPaused, GoalStatusCompleted, GoalStatusArchived},
+    GoalStatusPaused:    {GoalStatusActive, GoalStatusCompleted, GoalStatusArchived},
+    GoalStatusCompleted: {GoalStatusActive, GoalStatusArchived},
+    GoalStatusArchived:  {GoalStatusActive, GoalStatusCompleted},
+}
+
 // StarGoal 代表一个星目标
 type StarGoal struct {
-    ID          int       `json:"id" db:"id"`                    // 目标ID
-    Title       string    `json:"title" db:"title"`              // 目标标题
-    Description string    `json:"description" db:"description"`  // 目标描述
-    Category    string    `json:"category" db:"category"`        // 目标类别
-    Stars       int       `json:"stars" db:"stars"`              // 星数
-    CreatedAt   time.Time `json:"created_at" db:"created_at"`    // 创建时间
-    UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`    // 更新时间
-}
\ No newline at end of file
+    ID            int        `json:"id" db:"id"`                     // 目标ID
+    OwnerID       int        `json:"owner_id" db:"owner_id"`         // 所属用户ID
+    ParentID      *int       `json:"parent_id" db:"parent_id"`       // 父目标ID（顶层目标为空）
+    Title         string     `json:"title" db:"title"`               // 目标标题
+    Description   string     `json:"description" db:"description"`   // 目标描述
+    Category      string     `json:"category" db:"category"`         // 目标类别
+    Tags          []string   `json:"tags" db:"-"`                    // 标签名，保存在 goal_tags 关联表中
+    Stars         int        `json:"stars" db:"stars"`               // 星数（由星数流水汇总得出）
+    TargetStars   *int       `json:"target_stars" db:"target_stars"` // 目标星数，达到后目标自动完成（可以为空）
+    DueDate       *Date      `json:"due_date" db:"due_date"`         // 截止日期（可以为空）
+    Schedule      Schedule   `json:"schedule" db:"schedule"`         // 评分计划，默认每天
+    Progress      *float64   `json:"progress" db:"-"`                // 完成进度百分比，由星数和目标星数计算，未设置目标星数时为空
+    DaysRemaining *int       `json:"days_remaining" db:"-"`          // 距截止日期的剩余天数，已逾期时为负数，未设置截止日期时为空
+    CurrentStreak int        `json:"current_streak" db:"-"`          // 当前连续评分天数，按评分计划计算，今天尚未评分时截至上一个计划日期
+    LongestStreak int        `json:"longest_streak" db:"-"`          // 最近52周内最长连续评分天数
+    Compliance    *float64   `json:"compliance_rate" db:"-"`         // 计划完成率百分比：有评分的计划日期数 / 计划日期数，尚无计划日期时为空
+    Status        string     `json:"status" db:"status"`             // 目标状态，只能通过状态变更接口修改
+    CompletedAt   *time.Time `json:"completed_at" db:"completed_at"` // 完成时间
+    ArchivedAt    *time.Time `json:"archived_at" db:"archived_at"`   // 归档时间
+    CreatedAt     time.Time  `json:"created_at" db:"created_at"`     // 创建时间
+    UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`     // 更新时间
+    Children      []StarGoal `json:"children,omitempty" db:"-"`      // 子目标树，仅查询单个目标时返回
+    RollupStars   *int       `json:"rollup_stars,omitempty" db:"-"`  // 自身及所有子孙目标的星数合计，仅查询单个目标时返回
+}
+
+// ValidGoalStatus 判断状态是否有效
+func ValidGoalStatus(status string) bool {
+    _, ok := goalTransitions[status]
+    return ok
+}
+
+// CanTransition 判断目标能否从 from 状态变更为 to 状态
+func CanTransition(from, to string) bool {
+    for _, allowed := range goalTransitions[from] {
+        if allowed == to {
+            return true
+        }
+    }
+    return false
+}
+
+// TargetReached 判断目标是否设置了目标星数且已经达到
+func (g *StarGoal) TargetReached() bool {
+    return g.TargetStars != nil && g.Stars >= *g.TargetStars
+}
+
+// ComputeProgress 根据星数、目标星数和截止日期计算完成进度和剩余天数，today 为当前日期
+func (g *StarGoal) ComputeProgress(today Date) {
+    g.Progress, g.DaysRemaining = nil, nil
+    if g.TargetStars != nil && *g.TargetStars > 0 {
+        progress := math.Max(0, math.Min(100, float64(g.Stars)*100/float64(*g.TargetStars)))
+        progress = math.Round(progress*10) / 10
+        g.Progress = &progress
+    }
+    if g.DueDate != nil {
+        daysRemaining := today.DaysUntil(*g.DueDate)
+        g.DaysRemaining = &daysRemaining
+    }
+}
+
+// BuildGoalTree 将根目标的所有子孙目标组装为子目标树，并汇总每个节点的星数
+func BuildGoalTree(root StarGoal, descendants []StarGoal) StarGoal {
+    children := make(map[int][]StarGoal)
+    for _, goal := range descendants {
+        if goal.ParentID != nil {
+            children[*goal.ParentID] = append(children[*goal.ParentID], goal)
+        }
+    }
+
+    var build func(node StarGoal) StarGoal
+    build = func(node StarGoal) StarGoal {
+        rollup := node.Stars
+        node.Children = nil
+        for _, child := range children[node.ID] {
+            child = build(child)
+            rollup += *child.RollupStars
+            node.Children = append(node.Children, child)
+        }
+        node.RollupStars = &rollup
+        return node
+    }
+    return build(root)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// 星数流水类型
+const (
+	StarTransactionEarn   = "earn"   // 通过评分获得
+	StarTransactionAdjust = "adjust" // 修改评分或手动调整
+	StarTransactionSpend  = "spend"  // 消费星数
+)
+
+// 星数流水来源
+const (
+	StarSourceDailyRating = "daily_rating" // 每日评分
+	StarSourceManual      = "manual"       // 手动调整
+	StarSourceMigration   = "migration"    // 迁移时补录的历史星数
+	StarSourceRedemption  = "redemption"   // 兑换心愿及被拒绝后的退还
+)
+
+// StarTransaction 代表星数流水中的一条只追加记录，目标的星数由其流水汇总得出
+type StarTransaction struct {
+	ID        int       `json:"id" db:"id"`                 // 流水ID
+	GoalID    *int      `json:"goal_id" db:"goal_id"`       // 关联的目标ID（消费等与目标无关的流水为空）
+	OwnerID   *int      `json:"owner_id" db:"owner_id"`     // 流水所属的用户ID，关联目标的流水为目标的所属用户，目标删除后流水仍计入该用户的星数余额
+	Type      string    `json:"type" db:"type"`             // 流水类型：earn、adjust 或 spend
+	Amount    int       `json:"amount" db:"amount"`         // 星数变动，正数为增加，负数为减少
+	Reason    string    `json:"reason" db:"reason"`         // 变动原因
+	Source    string    `json:"source" db:"source"`         // 变动来源
+	SourceID  *int      `json:"source_id" db:"source_id"`   // 来源记录ID（如每日评分ID）
+	Balance   int       `json:"balance" db:"-"`             // 该笔流水之后的余额，查询历史时计算
+	CreatedAt time.Time `json:"created_at" db:"created_at"` // 创建时间
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+// RatingSummary 一组评分的次数、平均评分和分布
+type RatingSummary struct {
+	Count        int         `json:"count"`        // 评分次数
+	Average      *float64    `json:"average"`      // 平均评分，保留两位小数，没有评分时为空
+	Distribution map[int]int `json:"distribution"` // 1-5星各自的评分次数
+	sum          int
+}
+
+// StarTotal 一段时间内的星数变动合计
+type StarTotal struct {
+	Start Date `json:"start"` // 起始日期（含），第一个时间段从统计起始日期开始
+	End   Date `json:"end"`   // 结束日期（含），最后一个时间段到统计结束日期为止
+	Stars int  `json:"stars"` // 星数变动合计
+}
+
+// StarChange 一笔按日历日期归类的星数变动，用于统计
+type StarChange struct {
+	GoalID int  // 目标ID
+	Date   Date // 变动日期（按用户时区）
+	Amount int  // 星数变动
+}
+
+// WeekdayStats 某个星期几的评分统计
+type WeekdayStats struct {
+	Weekday int     `json:"weekday"` // 星期几，1 为周一，7 为周日
+	Count   int     `json:"count"`   // 评分次数
+	Average float64 `json:"average"` // 平均评分，保留两位小数
+}
+
+// GoalStats 单个目标的统计
+type GoalStats struct {
+	GoalID  int           `json:"goal_id"` // 目标ID
+	Title   string        `json:"title"`   // 目标标题
+	Ratings RatingSummary `json:"ratings"` // 评分统计
+	Stars   int           `json:"stars"`   // 统计范围内的星数变动合计
+}
+
+// Improvement 目标在统计范围后半段相对前半段的平均评分变化
+type Improvement struct {
+	GoalID       int     `json:"goal_id"`             // 目标ID
+	Title        string  `json:"title"`               // 目标标题
+	FirstAverage float64 `json:"first_half_average"`  // 前半段平均评分
+	LastAverage  float64 `json:"second_half_average"` // 后半段平均评分
+	Change       float64 `json:"change"`              // 平均评分的提高
+}
+
+// Stats 一段时间内评分和星数的统计
+type Stats struct {
+	From         Date           `json:"from"`           // 起始日期
+	To           Date           `json:"to"`             // 结束日期（含）
+	Ratings      RatingSummary  `json:"ratings"`        // 所有目标的评分统计
+	Stars        int            `json:"stars"`          // 所有目标的星数变动合计
+	StarsByWeek  []StarTotal    `json:"stars_by_week"`  // 每周（周一开始）的星数变动合计
+	StarsByMonth []StarTotal    `json:"stars_by_month"` // 每月的星数变动合计
+	Weekdays     []WeekdayStats `json:"weekdays"`       // 周一到周日有评分的各天的统计
+	BestWeekday  *WeekdayStats  `json:"best_weekday"`   // 平均评分最高的星期几，没有评分时为空
+	WorstWeekday *WeekdayStats  `json:"worst_weekday"`  // 平均评分最低的星期几，没有评分时为空
+	MostImproved *Improvement   `json:"most_improved"`  // 后半段平均评分提高最多的目标，没有提高的目标时为空
+	Goals        []GoalStats    `json:"goals"`          // 统计范围内有评分或星数变动的目标，按目标ID排序
+}
+
+// newRatingSummary 创建空的评分统计，分布中包含1-5星
+func newRatingSummary() RatingSummary {
+	return RatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+}
+
+// add 计入一条评分
+func (r *RatingSummary) add(rating int) {
+	r.Count++
+	r.sum += rating
+	r.Distribution[rating]++
+	average := roundAverage(r.sum, r.Count)
+	r.Average = &average
+}
+
+// ComputeStats 统计 from 到 to（含）之间的评分和星数变动，goals 用于查找目标标题
+func ComputeStats(ratings []DailyRating, changes []StarChange, goals []StarGoal, from, to Date) Stats {
+	stats := Stats{
+		From:         from,
+		To:           to,
+		Ratings:      newRatingSummary(),
+		StarsByWeek:  starTotals(changes, from, to, GranularityWeek),
+		StarsByMonth: starTotals(changes, from, to, GranularityMonth),
+		Weekdays:     []WeekdayStats{},
+		Goals:        []GoalStats{},
+	}
+	titles := make(map[int]string, len(goals))
+	for _, goal := range goals {
+		titles[goal.ID] = goal.Title
+	}
+	byGoal := make(map[int]*GoalStats)
+	goalStats := func(goalID int) *GoalStats {
+		if _, ok := byGoal[goalID]; !ok {
+			byGoal[goalID] = &GoalStats{GoalID: goalID, Title: titles[goalID], Ratings: newRatingSummary()}
+		}
+		return byGoal[goalID]
+	}
+
+	// 汇总评分，同时按星期几和前后半段分别累计
+	var weekdaySum, weekdayCount [8]int
+	mid := Date{from.AddDate(0, 0, (from.DaysUntil(to)+1)/2)}
+	halves := make(map[int]*[2][2]int)
+	for _, rating := range ratings {
+		if rating.Date.Before(from.Time) || rating.Date.After(to.Time) {
+			continue
+		}
+		stats.Ratings.add(rating.Rating)
+		goalStats(rating.GoalID).Ratings.add(rating.Rating)
+
+		weekday := isoWeekday(rating.Date)
+		weekdaySum[weekday] += rating.Rating
+		weekdayCount[weekday]++
+
+		if halves[rating.GoalID] == nil {
+			halves[rating.GoalID] = &[2][2]int{}
+		}
+		half := 0
+		if !rating.Date.Before(mid.Time) {
+			half = 1
+		}
+		halves[rating.GoalID][half][0] += rating.Rating
+		halves[rating.GoalID][half][1]++
+	}
+	for _, change := range changes {
+		if change.Date.Before(from.Time) || change.Date.After(to.Time) {
+			continue
+		}
+		stats.Stars += change.Amount
+		goalStats(change.GoalID).Stars += change.Amount
+	}
+
+	// 星期几统计，平均评分相同时评分次数多的更好
+	for weekday := 1; weekday <= 7; weekday++ {
+		if weekdayCount[weekday] > 0 {
+			stats.Weekdays = append(stats.Weekdays, WeekdayStats{
+				Weekday: weekday,
+				Count:   weekdayCount[weekday],
+				Average: roundAverage(weekdaySum[weekday], weekdayCount[weekday]),
+			})
+		}
+	}
+	for i := range stats.Weekdays {
+		weekday := stats.Weekdays[i]
+		if stats.BestWeekday == nil || weekday.Average > stats.BestWeekday.Average ||
+			(weekday.Average == stats.BestWeekday.Average && weekday.Count > stats.BestWeekday.Count) {
+			stats.BestWeekday = &weekday
+		}
+		if stats.WorstWeekday == nil || weekday.Average < stats.WorstWeekday.Average ||
+			(weekday.Average == stats.WorstWeekday.Average && weekday.Count > stats.WorstWeekday.Count) {
+			stats.WorstWeekday = &weekday
+		}
+	}
+
+	// 前后半段都有评分的目标中平均评分提高最多的目标
+	ids := make([]int, 0, len(byGoal))
+	for id := range byGoal {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		stats.Goals = append(stats.Goals, *byGoal[id])
+		half, ok := halves[id]
+		if !ok || half[0][1] == 0 || half[1][1] == 0 {
+			continue
+		}
+		first, last := roundAverage(half[0][0], half[0][1]), roundAverage(half[1][0], half[1][1])
+		change := math.Round((last-first)*100) / 100
+		if change > 0 && (stats.MostImproved == nil || change > stats.MostImproved.Change) {
+			stats.MostImproved = &Improvement{GoalID: id, Title: titles[id], FirstAverage: first, LastAverage: last, Change: change}
+		}
+	}
+	return stats
+}
+
+// starTotals 将 from 到 to（含）之间的星数变动按粒度汇总，返回连续的时间段
+func starTotals(changes []StarChange, from, to Date, granularity string) []StarTotal {
+	totals := []StarTotal{}
+	index := make(map[string]int)
+	for start := bucketStart(from, granularity); !start.After(to.Time); start = nextBucket(start, granularity) {
+		total := StarTotal{Start: start, End: Date{nextBucket(start, granularity).AddDate(0, 0, -1)}}
+		if total.Start.Before(from.Time) {
+			total.Start = from
+		}
+		if total.End.After(to.Time) {
+			total.End = to
+		}
+		index[start.String()] = len(totals)
+		totals = append(totals, total)
+	}
+	for _, change := range changes {
+		if change.Date.Before(from.Time) || change.Date.After(to.Time) {
+			continue
+		}
+		totals[index[bucketStart(change.Date, granularity).String()]].Stars += change.Amount
+	}
+	return totals
+}
+
+// roundAverage 计算平均值并保留两位小数
+func roundAverage(sum, count int) float64 {
+	return math.Round(float64(sum)/float64(count)*100) / 100
+}
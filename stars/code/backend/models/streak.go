@@ -0,0 +1,17 @@
+package models
+
+// Streak 一段连续每个计划日期都有达标评分的时间，由 Schedule.Streaks 计算
+type Streak struct {
+	Start Date `json:"start"` // 起始日期
+	End   Date `json:"end"`   // 结束日期（含）
+	Days  int  `json:"days"`  // 连续评分的天数
+}
+
+// LongestStreak 返回最长的连续天数
+func LongestStreak(streaks []Streak) int {
+	longest := 0
+	for _, streak := range streaks {
+		longest = max(longest, streak.Days)
+	}
+	return longest
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Tag 代表用户的一个标签，一个目标可以有多个标签
+type Tag struct {
+	ID        int       `json:"id" db:"id"`                 // 标签ID
+	OwnerID   int       `json:"owner_id" db:"owner_id"`     // 所属用户ID
+	Name      string    `json:"name" db:"name"`             // 标签名（规范化后在同一用户内唯一）
+	GoalCount int       `json:"goal_count" db:"-"`          // 使用该标签的目标数，查询标签列表时计算
+	CreatedAt time.Time `json:"created_at" db:"created_at"` // 创建时间
+}
+
+// NormalizeTagName 规范化标签名：去掉首尾空白、合并连续空白并转为小写，避免大小写和空格不同产生重复标签
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// User 代表一个用户账号
+type User struct {
+	ID                int       `json:"id" db:"id"`                                   // 用户ID
+	Username          string    `json:"username" db:"username"`                       // 用户名（不区分大小写，唯一）
+	PasswordHash      string    `json:"-" db:"password_hash"`                         // bcrypt 密码哈希，不返回给客户端
+	Timezone          string    `json:"timezone" db:"timezone"`                       // IANA 时区名，为空时使用默认时区
+	LeaderboardOptOut bool      `json:"leaderboard_opt_out" db:"leaderboard_opt_out"` // 是否不参加排行榜
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`                   // 创建时间
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// 心愿兑换状态
+const (
+	RedemptionPending  = "pending"  // 等待审批人同意，星数已经扣除
+	RedemptionGranted  = "granted"  // 已兑现
+	RedemptionRejected = "rejected" // 审批人拒绝，星数已经退还
+)
+
+// Wish 代表心愿池中的一个心愿，可以用星数兑换
+type Wish struct {
+	ID          int       `json:"id" db:"id"`                   // 心愿ID
+	OwnerID     int       `json:"owner_id" db:"owner_id"`       // 所属用户ID
+	Title       string    `json:"title" db:"title"`             // 心愿标题
+	Description string    `json:"description" db:"description"` // 心愿描述
+	Price       int       `json:"price" db:"price"`             // 兑换所需的星数
+	ApproverID  *int      `json:"approver_id" db:"approver_id"` // 审批人的用户ID，为空表示兑换无需审批
+	Approver    string    `json:"approver" db:"-"`              // 审批人的用户名
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`   // 创建时间
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`   // 更新时间
+}
+
+// Redemption 一次心愿兑换，保存兑换时的心愿标题和价格
+type Redemption struct {
+	ID         int        `json:"id" db:"id"`                   // 兑换记录ID
+	WishID     *int       `json:"wish_id" db:"wish_id"`         // 心愿ID，心愿删除后为空
+	UserID     int        `json:"user_id" db:"user_id"`         // 兑换的用户ID
+	Username   string     `json:"username" db:"-"`              // 兑换的用户名
+	Title      string     `json:"title" db:"title"`             // 兑换时的心愿标题
+	Price      int        `json:"price" db:"price"`             // 兑换时扣除的星数
+	Status     string     `json:"status" db:"status"`           // 兑换状态：pending、granted 或 rejected
+	ApproverID *int       `json:"approver_id" db:"approver_id"` // 审批人的用户ID，无需审批时为空
+	Approver   string     `json:"approver" db:"-"`              // 审批人的用户名
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`   // 兑换时间
+	DecidedAt  *time.Time `json:"decided_at" db:"decided_at"`   // 兑现或拒绝的时间，等待审批时为空
+}
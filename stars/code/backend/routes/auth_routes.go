@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"starpool/auth"
+	"starpool/controllers"
+	"starpool/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAuthRoutes 注册用户注册、登录相关的路由，注册和登录无需认证
+func RegisterAuthRoutes(router *gin.Engine, users store.UserStore, tokens *auth.TokenManager) {
+	authController := &controllers.AuthController{Users: users, Tokens: tokens}
+
+	// 公开路由
+	router.POST("/auth/register", authController.Register)
+	router.POST("/auth/login", authController.Login)
+
+	// 需要认证的路由
+	router.GET("/auth/me", auth.RequireAuth(tokens), authController.GetCurrentUser)
+	router.PUT("/auth/me", auth.RequireAuth(tokens), authController.UpdateCurrentUser)
+}
@@ -1,30 +1,88 @@
 package routes
 
 import (
+	"starpool/achievements"
+	"starpool/auth"
+	"starpool/config"
 	"starpool/controllers"
+	"starpool/store"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterGoalRoutes 注册星目标相关的路由
-func RegisterGoalRoutes(router *gin.Engine) {
-	goalController := &controllers.GoalController{}
-	commentController := &controllers.CommentController{}
+// RegisterGoalRoutes 注册星目标相关的路由，所有路由都需要认证
+func RegisterGoalRoutes(router *gin.Engine, s store.Store, tokens *auth.TokenManager) {
+	clock := &controllers.Clock{Users: s, Default: config.Timezone()}
+	engine := &achievements.Engine{Goals: s, Ratings: s, Achievements: s, StreakMinRating: config.StreakMinRating()}
+	goalController := &controllers.GoalController{Goals: s, Ratings: s, StreakMinRating: config.StreakMinRating(), Clock: clock, Achievements: engine}
+	commentController := &controllers.CommentController{Goals: s, Comments: s}
+	starController := &controllers.StarController{Goals: s, Ledger: s}
+	searchController := &controllers.SearchController{Documents: s}
+	tagController := &controllers.TagController{Tags: s}
+	categoryController := &controllers.CategoryController{Categories: s, Clock: clock}
+	journalController := &controllers.JournalController{Ratings: s}
+	statsController := &controllers.StatsController{Goals: s, Ratings: s, Ledger: s, Clock: clock}
+	leaderboardController := &controllers.LeaderboardController{Ratings: s, Users: s, Clock: clock}
+	achievementController := &controllers.AchievementController{Achievements: engine, Clock: clock}
+	wishController := &controllers.WishController{Wishes: s, Users: s}
+
+	authorized := router.Group("", auth.RequireAuth(tokens))
 
 	// 目标管理路由
-	router.POST("/goals", goalController.CreateGoal)
-	router.GET("/goals", goalController.GetGoals)
-	router.GET("/goals/:id", goalController.GetGoalByID)
-	router.PUT("/goals/:id", goalController.UpdateGoal)
-	router.DELETE("/goals/:id", goalController.DeleteGoal)
-	router.GET("/goals/category/:category", goalController.GetGoalsByCategory)
+	authorized.POST("/goals", goalController.CreateGoal)
+	authorized.GET("/goals", goalController.GetGoals)
+	authorized.GET("/goals/overdue", goalController.GetOverdueGoals)
+	authorized.GET("/today", goalController.GetToday)
+	authorized.GET("/goals/:id", goalController.GetGoalByID)
+	authorized.PUT("/goals/:id", goalController.UpdateGoal)
+	authorized.DELETE("/goals/:id", goalController.DeleteGoal)
+	authorized.PUT("/goals/:id/status", goalController.UpdateGoalStatus)
+	authorized.GET("/goals/category/:category", goalController.GetGoalsByCategory)
 	// 添加获取总星数的路由
-	router.GET("/stars", goalController.GetTotalStars)
+	authorized.GET("/stars", goalController.GetTotalStars)
+	authorized.GET("/stats", statsController.GetStats)
+	authorized.GET("/heatmap", statsController.GetHeatmap)
+	authorized.GET("/leaderboard", leaderboardController.GetLeaderboard)
+	authorized.GET("/achievements", achievementController.GetAchievements)
 	// 添加每日评分路由
-	router.POST("/goals/:id/daily-rating", goalController.AddDailyRating)
-	router.GET("/goals/:id/daily-ratings", goalController.GetDailyRatings)
-	
+	authorized.POST("/goals/:id/daily-rating", goalController.AddDailyRating)
+	authorized.GET("/goals/:id/daily-ratings", goalController.GetDailyRatings)
+	authorized.GET("/goals/:id/streaks", goalController.GetGoalStreaks)
+	authorized.GET("/journal", journalController.GetJournal)
+	// 添加星数流水路由
+	authorized.GET("/goals/:id/stars/history", starController.GetStarHistory)
+	authorized.POST("/goals/:id/stars/adjustments", starController.AdjustStars)
+
+	// 添加心愿池路由
+	authorized.GET("/wishes", wishController.GetWishes)
+	authorized.POST("/wishes", wishController.CreateWish)
+	authorized.PUT("/wishes/:id", wishController.UpdateWish)
+	authorized.DELETE("/wishes/:id", wishController.DeleteWish)
+	authorized.POST("/wishes/:id/redeem", wishController.RedeemWish)
+	authorized.GET("/redemptions", wishController.GetRedemptions)
+	authorized.GET("/redemptions/approvals", wishController.GetApprovals)
+	authorized.POST("/redemptions/:id/approve", wishController.ApproveRedemption)
+	authorized.POST("/redemptions/:id/reject", wishController.RejectRedemption)
+
 	// 添加评论路由
-	router.POST("/goals/:id/comments", commentController.CreateComment)
-	router.GET("/goals/:id/comments", commentController.GetCommentsByGoalID)
+	authorized.POST("/goals/:id/comments", commentController.CreateComment)
+	authorized.GET("/goals/:id/comments", commentController.GetCommentsByGoalID)
+	authorized.PUT("/goals/:id/comments/:commentId", commentController.UpdateComment)
+	authorized.DELETE("/goals/:id/comments/:commentId", commentController.DeleteComment)
+	authorized.GET("/goals/:id/comments/:commentId/history", commentController.GetCommentHistory)
+
+	// 添加标签路由
+	authorized.GET("/tags", tagController.GetTags)
+	authorized.PUT("/tags/:id", tagController.RenameTag)
+	authorized.DELETE("/tags/:id", tagController.DeleteTag)
+	authorized.POST("/tags/merge", tagController.MergeTags)
+
+	// 添加类别路由
+	authorized.GET("/categories", categoryController.GetCategories)
+	authorized.POST("/categories", categoryController.CreateCategory)
+	authorized.PUT("/categories/:id", categoryController.UpdateCategory)
+	authorized.DELETE("/categories/:id", categoryController.DeleteCategory)
+
+	// 添加搜索路由
+	authorized.GET("/search", searchController.Search)
 }
@@ -0,0 +1,191 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 文档类型
+const (
+	DocumentGoal    = "goal"
+	DocumentComment = "comment"
+)
+
+const (
+	// titleWeight 标题中命中检索词的权重，正文为1
+	titleWeight = 3
+	// maxTermCount 同一检索词在一个字段中计分的最多次数，避免重复堆砌的文本排在前面
+	maxTermCount = 3
+	// snippetLength 摘要的最大字符数
+	snippetLength = 80
+	// snippetLead 摘要中第一个命中位置之前保留的字符数
+	snippetLead = 20
+)
+
+// Document 待排序的候选文档，由存储层按检索词粗筛得到
+type Document struct {
+	Type      string // 文档类型，DocumentGoal 或 DocumentComment
+	ID        int    // 目标ID或评论ID
+	GoalID    int    // 所属目标ID
+	GoalTitle string // 所属目标标题
+	Title     string // 标题，仅目标有
+	Body      string // 正文，目标的描述或评论内容
+}
+
+// GoalRef 命中结果所属的目标
+type GoalRef struct {
+	ID    int    `json:"id"`    // 目标ID
+	Title string `json:"title"` // 目标标题
+}
+
+// Hit 一条搜索结果
+type Hit struct {
+	Type    string  `json:"type"`            // 结果类型：goal 或 comment
+	ID      int     `json:"id"`              // 目标ID或评论ID
+	Score   float64 `json:"score"`           // 相关度得分，越高越相关
+	Title   string  `json:"title,omitempty"` // 高亮后的目标标题，仅目标结果有
+	Snippet string  `json:"snippet"`         // 高亮后的正文摘要，已做HTML转义，命中部分以 <mark> 标出
+	Goal    GoalRef `json:"goal"`            // 所属目标
+}
+
+// Rank 按相关度对候选文档打分排序，返回得分最高的 limit 条结果
+// 得分综合命中的检索词比例、命中次数和字段权重，完整包含查询短语的文档额外加分；
+// 命中的检索词不足一半的文档会被过滤掉
+func Rank(query string, documents []Document, limit int) []Hit {
+	terms := Tokenize(query)
+	hits := []Hit{}
+	if len(terms) == 0 {
+		return hits
+	}
+	needles := make([][]rune, len(terms))
+	for i, term := range terms {
+		needles[i] = []rune(term)
+	}
+	phrase := lowerRunes(strings.TrimSpace(query))
+	minMatched := (len(terms) + 1) / 2
+
+	for _, document := range documents {
+		title := lowerRunes(document.Title)
+		body := lowerRunes(document.Body)
+
+		matched, score := 0, 0.0
+		for _, needle := range needles {
+			inTitle := min(len(indexAll(title, needle)), maxTermCount)
+			inBody := min(len(indexAll(body, needle)), maxTermCount)
+			if inTitle+inBody > 0 {
+				matched++
+			}
+			score += float64(titleWeight*inTitle + inBody)
+		}
+		if matched < minMatched {
+			continue
+		}
+
+		coverage := float64(matched) / float64(len(terms))
+		score = score / float64(len(terms)) * coverage
+		if len(indexAll(title, phrase)) > 0 {
+			score += titleWeight
+		} else if len(indexAll(body, phrase)) > 0 {
+			score++
+		}
+
+		hit := Hit{
+			Type:    document.Type,
+			ID:      document.ID,
+			Score:   math.Round(score*1000) / 1000,
+			Snippet: highlight(document.Body, needles, true),
+			Goal:    GoalRef{ID: document.GoalID, Title: document.GoalTitle},
+		}
+		if document.Type == DocumentGoal {
+			hit.Title = highlight(document.Title, needles, false)
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Type != hits[j].Type {
+			return hits[i].Type == DocumentGoal
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// highlight 对文本做HTML转义并用 <mark> 标出命中的检索词
+// truncate 为 true 时只截取第一个命中位置附近的摘要
+func highlight(text string, needles [][]rune, truncate bool) string {
+	runes := []rune(text)
+	lowered := lowerRunes(text)
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, needle := range needles {
+		for _, index := range indexAll(lowered, needle) {
+			for i := index; i < index+len(needle); i++ {
+				marked[i] = true
+			}
+			if first == -1 || index < first {
+				first = index
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if truncate && len(runes) > snippetLength {
+		start = max(0, first-snippetLead)
+		end = min(len(runes), start+snippetLength)
+		start = max(0, end-snippetLength)
+	}
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			segment = "<mark>" + segment + "</mark>"
+		}
+		builder.WriteString(segment)
+		i = j
+	}
+	if end < len(runes) {
+		builder.WriteString("…")
+	}
+	return builder.String()
+}
+
+// indexAll 返回 needle 在 haystack 中所有出现位置（按字符计）
+func indexAll(haystack, needle []rune) []int {
+	var indexes []int
+	if len(needle) == 0 {
+		return indexes
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// lowerRunes 逐字符转为小写，保证与原文的字符位置一一对应
+func lowerRunes(text string) []rune {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
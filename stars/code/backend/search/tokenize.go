@@ -0,0 +1,62 @@
+// Package search 实现目标和评论的全文搜索：分词、相关度排序和摘要高亮
+// 中文文本没有空格分词，连续的汉字按二元组（bigram）切分，字母和数字按单词切分
+package search
+
+import (
+	"unicode"
+)
+
+// Tokenize 将文本切分为小写的检索词并去重，保持首次出现的顺序
+// 连续汉字切分为相邻两字的二元组，只有一个汉字时保留单字；字母和数字连续的部分作为一个单词
+func Tokenize(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	var run []rune
+	cjk := false
+	flush := func() {
+		switch {
+		case len(run) == 0:
+		case !cjk || len(run) == 1:
+			add(string(run))
+		default:
+			for i := 0; i+1 < len(run); i++ {
+				add(string(run[i : i+2]))
+			}
+		}
+		run = run[:0]
+	}
+
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		switch {
+		case isCJK(r):
+			if !cjk {
+				flush()
+				cjk = true
+			}
+			run = append(run, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if cjk {
+				flush()
+				cjk = false
+			}
+			run = append(run, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
+
+// isCJK 判断字符是否属于需要按 n-gram 切分的中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"starpool/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 目标列表支持的排序字段
+const (
+	GoalSortStars     = "stars"
+	GoalSortCreatedAt = "created_at"
+	GoalSortUpdatedAt = "updated_at"
+	GoalSortTitle     = "title"
+)
+
+// ErrInvalidCursor 表示分页游标无法解析或与排序方式不匹配
+var ErrInvalidCursor = errors.New("无效的分页游标")
+
+// GoalQuery 目标列表的过滤、排序和分页条件
+type GoalQuery struct {
+	OwnerID     int          // 所属用户ID
+	Category    string       // 类别，为空时不过滤
+	Statuses    []string     // 目标状态，为空时不过滤
+	Tags        []string     // 标签名（已规范化），为空时不过滤
+	AllTags     bool         // 为 true 时目标须包含所有标签，否则包含任一标签即可
+	MinStars    *int         // 最少星数（含）
+	MaxStars    *int         // 最多星数（含）
+	CreatedFrom *time.Time   // 创建时间下限（含）
+	CreatedTo   *time.Time   // 创建时间上限（不含）
+	DueBefore   *models.Date // 截止日期早于该日期（不含），未设置截止日期的目标不匹配
+	BelowTarget bool         // 只返回未达到目标星数的目标，未设置目标星数的目标视为未达到
+	Sort        string       // 排序字段，取值为 GoalSort* 常量
+	Desc        bool         // 是否倒序
+	Limit       int          // 每页条数
+	Cursor      string       // 上一页返回的游标，为空时从第一页开始
+}
+
+// GoalPage 一页目标列表
+type GoalPage struct {
+	Goals      []models.StarGoal `json:"goals"`       // 当前页的目标
+	NextCursor string            `json:"next_cursor"` // 下一页的游标，没有更多数据时为空
+	Total      int               `json:"total"`       // 满足过滤条件的目标总数
+}
+
+// goalCursor 分页游标的内容，记录上一页最后一个目标的排序值和ID
+type goalCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// ValidGoalSort 判断排序字段是否受支持
+func ValidGoalSort(sort string) bool {
+	switch sort {
+	case GoalSortStars, GoalSortCreatedAt, GoalSortUpdatedAt, GoalSortTitle:
+		return true
+	}
+	return false
+}
+
+// encodeGoalCursor 根据目标和排序字段生成游标
+func encodeGoalCursor(goal models.StarGoal, sort string) string {
+	data, _ := json.Marshal(goalCursor{Sort: sort, Value: goalSortValue(goal, sort), ID: goal.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeGoalCursor 解析游标，检查游标与当前排序字段一致，
+// 并返回一个仅填充了排序值和ID的目标，作为本页的起始位置
+func decodeGoalCursor(cursor string, sort string) (models.StarGoal, error) {
+	var anchor models.StarGoal
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return anchor, ErrInvalidCursor
+	}
+	var decoded goalCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Sort != sort {
+		return anchor, ErrInvalidCursor
+	}
+
+	anchor.ID = decoded.ID
+	switch sort {
+	case GoalSortStars:
+		anchor.Stars, err = strconv.Atoi(decoded.Value)
+	case GoalSortUpdatedAt:
+		anchor.UpdatedAt, err = time.Parse(time.RFC3339Nano, decoded.Value)
+	case GoalSortTitle:
+		anchor.Title = decoded.Value
+	default:
+		anchor.CreatedAt, err = time.Parse(time.RFC3339Nano, decoded.Value)
+	}
+	if err != nil {
+		return anchor, ErrInvalidCursor
+	}
+	return anchor, nil
+}
+
+// goalSortValue 以字符串形式返回目标在排序字段上的值，时间统一为 UTC
+func goalSortValue(goal models.StarGoal, sort string) string {
+	switch sort {
+	case GoalSortStars:
+		return strconv.Itoa(goal.Stars)
+	case GoalSortUpdatedAt:
+		return goal.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case GoalSortTitle:
+		return goal.Title
+	default:
+		return goal.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// compareGoals 按排序字段比较两个目标，值相同时按ID比较，返回 -1、0 或 1
+func compareGoals(a, b models.StarGoal, sort string) int {
+	var result int
+	switch sort {
+	case GoalSortStars:
+		result = compareInts(a.Stars, b.Stars)
+	case GoalSortUpdatedAt:
+		result = a.UpdatedAt.Compare(b.UpdatedAt)
+	case GoalSortTitle:
+		result = strings.Compare(a.Title, b.Title)
+	default:
+		result = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if result == 0 {
+		result = compareInts(a.ID, b.ID)
+	}
+	return result
+}
+
+// compareInts 比较两个整数，返回 -1、0 或 1
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
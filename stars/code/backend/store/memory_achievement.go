@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"starpool/models"
+	"time"
+)
+
+// AddAchievement 记录用户获得的成就
+func (s *MemoryStore) AddAchievement(ctx context.Context, achievement *models.Achievement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.achievements {
+		if existing.UserID == achievement.UserID && existing.Badge == achievement.Badge {
+			return ErrDuplicate
+		}
+	}
+	s.nextID.achievement++
+	achievement.ID = s.nextID.achievement
+	achievement.EarnedAt = time.Now()
+	s.achievements = append(s.achievements, *achievement)
+	return nil
+}
+
+// ListAchievements 获取用户获得的所有成就
+func (s *MemoryStore) ListAchievements(ctx context.Context, userID int) ([]models.Achievement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	achievements := []models.Achievement{}
+	for _, achievement := range s.achievements {
+		if achievement.UserID == userID {
+			achievements = append(achievements, achievement)
+		}
+	}
+	return achievements, nil
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"starpool/models"
+	"time"
+)
+
+// ListCategories 获取用户的所有类别及其目标数、星数合计和 since 之后的评分情况
+func (s *MemoryStore) ListCategories(ctx context.Context, ownerID int, since models.Date) ([]models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := []models.Category{}
+	for _, category := range s.categories {
+		if category.OwnerID == ownerID {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+
+	byName := make(map[string]*models.Category, len(categories))
+	for i := range categories {
+		byName[categories[i].Name] = &categories[i]
+	}
+	for _, goal := range s.goals {
+		if category, ok := byName[goal.Category]; ok && goal.OwnerID == ownerID {
+			category.GoalCount++
+			category.TotalStars += s.goalStars(goal.ID)
+		}
+	}
+
+	var ratings []models.DailyRating
+	for _, rating := range s.ratings {
+		if !rating.Date.Before(since.Time) {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool { return ratings[i].Date.Before(ratings[j].Date.Time) })
+	for _, rating := range ratings {
+		goal := s.goals[rating.GoalID]
+		if category, ok := byName[goal.Category]; ok && goal.OwnerID == ownerID {
+			addCategoryActivity(category, rating.Date, rating.Rating)
+		}
+	}
+	return categories, nil
+}
+
+// GetCategory 根据ID获取类别
+func (s *MemoryStore) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &category, nil
+}
+
+// CreateCategory 创建新类别
+func (s *MemoryStore) CreateCategory(ctx context.Context, category *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findCategory(category.OwnerID, category.Name); ok {
+		return ErrDuplicate
+	}
+	s.nextID.category++
+	now := time.Now()
+	category.ID = s.nextID.category
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	s.categories[category.ID] = *category
+	return nil
+}
+
+// UpdateCategory 更新类别，改名时同步修改该类别下目标的类别名
+func (s *MemoryStore) UpdateCategory(ctx context.Context, category *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.categories[category.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if other, ok := s.findCategory(existing.OwnerID, category.Name); ok && other.ID != category.ID {
+		return ErrDuplicate
+	}
+	s.renameGoalCategory(existing.OwnerID, existing.Name, category.Name)
+
+	existing.Name = category.Name
+	existing.Color = category.Color
+	existing.Icon = category.Icon
+	existing.SortOrder = category.SortOrder
+	existing.WeeklyStarBudget = category.WeeklyStarBudget
+	existing.UpdatedAt = time.Now()
+	s.categories[category.ID] = existing
+	return nil
+}
+
+// DeleteCategory 删除类别并清空该类别下目标的类别名
+func (s *MemoryStore) DeleteCategory(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return ErrNotFound
+	}
+	s.renameGoalCategory(category.OwnerID, category.Name, "")
+	delete(s.categories, id)
+	return nil
+}
+
+// renameGoalCategory 将用户类别名为 from 的目标改为 to，调用方需持有写锁
+func (s *MemoryStore) renameGoalCategory(ownerID int, from, to string) {
+	if from == to {
+		return
+	}
+	for id, goal := range s.goals {
+		if goal.OwnerID == ownerID && goal.Category == from {
+			goal.Category = to
+			goal.UpdatedAt = time.Now()
+			s.goals[id] = goal
+		}
+	}
+}
+
+// ensureCategory 确保用户有名为 name 的类别，不存在时以默认设置创建，调用方需持有写锁
+func (s *MemoryStore) ensureCategory(ownerID int, name string) {
+	if name == "" {
+		return
+	}
+	if _, ok := s.findCategory(ownerID, name); ok {
+		return
+	}
+	s.nextID.category++
+	now := time.Now()
+	s.categories[s.nextID.category] = models.Category{
+		ID:        s.nextID.category,
+		OwnerID:   ownerID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// findCategory 按名称查找用户的类别，调用方需持有读锁
+func (s *MemoryStore) findCategory(ownerID int, name string) (models.Category, bool) {
+	for _, category := range s.categories {
+		if category.OwnerID == ownerID && category.Name == name {
+			return category, true
+		}
+	}
+	return models.Category{}, false
+}
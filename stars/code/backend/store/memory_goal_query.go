@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"starpool/models"
+)
+
+// ListGoals 按过滤、排序和分页条件获取用户的一页目标
+func (s *MemoryStore) ListGoals(ctx context.Context, query GoalQuery) (*GoalPage, error) {
+	var anchor *models.StarGoal
+	if query.Cursor != "" {
+		decoded, err := decodeGoalCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		anchor = &decoded
+	}
+
+	goals := s.filterGoals(func(goal models.StarGoal) bool { return matchGoalQuery(goal, query) })
+	sort.Slice(goals, func(i, j int) bool {
+		if query.Desc {
+			return compareGoals(goals[i], goals[j], query.Sort) > 0
+		}
+		return compareGoals(goals[i], goals[j], query.Sort) < 0
+	})
+
+	page := &GoalPage{Goals: []models.StarGoal{}, Total: len(goals)}
+	for _, goal := range goals {
+		if anchor != nil {
+			order := compareGoals(goal, *anchor, query.Sort)
+			if (query.Desc && order >= 0) || (!query.Desc && order <= 0) {
+				continue
+			}
+		}
+		if query.Limit > 0 && len(page.Goals) == query.Limit {
+			last := page.Goals[len(page.Goals)-1]
+			page.NextCursor = encodeGoalCursor(last, query.Sort)
+			break
+		}
+		page.Goals = append(page.Goals, goal)
+	}
+	return page, nil
+}
+
+// matchGoalQuery 判断目标是否满足查询的过滤条件（不含游标）
+func matchGoalQuery(goal models.StarGoal, query GoalQuery) bool {
+	if goal.OwnerID != query.OwnerID {
+		return false
+	}
+	if query.Category != "" && goal.Category != query.Category {
+		return false
+	}
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, goal.Status) {
+		return false
+	}
+	if len(query.Tags) > 0 && !matchGoalTags(goal.Tags, normalizeTags(query.Tags), query.AllTags) {
+		return false
+	}
+	if query.MinStars != nil && goal.Stars < *query.MinStars {
+		return false
+	}
+	if query.MaxStars != nil && goal.Stars > *query.MaxStars {
+		return false
+	}
+	if query.CreatedFrom != nil && goal.CreatedAt.Before(*query.CreatedFrom) {
+		return false
+	}
+	if query.CreatedTo != nil && !goal.CreatedAt.Before(*query.CreatedTo) {
+		return false
+	}
+	if query.DueBefore != nil && (goal.DueDate == nil || !goal.DueDate.Before(query.DueBefore.Time)) {
+		return false
+	}
+	if query.BelowTarget && goal.TargetReached() {
+		return false
+	}
+	return true
+}
+
+// matchGoalTags 判断目标的标签是否包含查询标签中的任意一个，all 为 true 时需包含全部
+func matchGoalTags(goalTags, tags []string, all bool) bool {
+	for _, tag := range tags {
+		if slices.Contains(goalTags, tag) != all {
+			return !all
+		}
+	}
+	return all
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"starpool/models"
+	"time"
+)
+
+// ListGoalDescendants 获取目标的所有子孙目标
+func (s *MemoryStore) ListGoalDescendants(ctx context.Context, id int) ([]models.StarGoal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	today := models.DateOf(time.Now())
+	descendants := s.descendants(id)
+	for i := range descendants {
+		descendants[i].Stars = s.goalStars(descendants[i].ID)
+		descendants[i].Tags = s.goalTagNames(descendants[i].ID)
+		descendants[i].ComputeProgress(today)
+	}
+	return descendants, nil
+}
+
+// descendants 逐层查找目标的所有子孙目标，调用方需持有读锁
+func (s *MemoryStore) descendants(id int) []models.StarGoal {
+	var descendants []models.StarGoal
+	level := map[int]bool{id: true}
+	for len(level) > 0 {
+		next := make(map[int]bool)
+		for _, goal := range s.goals {
+			if goal.ParentID != nil && level[*goal.ParentID] {
+				descendants = append(descendants, goal)
+				next[goal.ID] = true
+			}
+		}
+		level = next
+	}
+	sort.Slice(descendants, func(i, j int) bool { return descendants[i].ID < descendants[j].ID })
+	return descendants
+}
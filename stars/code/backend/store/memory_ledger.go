@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"starpool/models"
+	"time"
+)
+
+// AddStarTransaction 追加一笔星数流水，并回填该笔流水后目标的星数余额，关联的目标不存在时返回 ErrNotFound，
+// 扣减星数使目标星数或所属用户的星数余额为负时返回 ErrInsufficientStars
+// 关联目标的星数因此达到目标星数时，目标自动完成
+func (s *MemoryStore) AddStarTransaction(ctx context.Context, transaction *models.StarTransaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if transaction.GoalID == nil {
+		s.appendTransaction(transaction)
+		return nil
+	}
+	goal, ok := s.goals[*transaction.GoalID]
+	if !ok {
+		return ErrNotFound
+	}
+	if transaction.Amount < 0 {
+		if s.goalStars(goal.ID)+transaction.Amount < 0 || (goal.OwnerID != 0 && s.userStars(goal.OwnerID)+transaction.Amount < 0) {
+			return ErrInsufficientStars
+		}
+	}
+	s.appendTransaction(transaction)
+	transaction.Balance = s.goalStars(goal.ID)
+	s.completeIfTargetReached(goal.ID)
+	return nil
+}
+
+// ListStarTransactions 按时间顺序返回目标的星数流水，并计算每笔流水后的余额
+func (s *MemoryStore) ListStarTransactions(ctx context.Context, goalID int) ([]models.StarTransaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var transactions []models.StarTransaction
+	for _, transaction := range s.transactions {
+		if transaction.GoalID != nil && *transaction.GoalID == goalID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return withRunningBalance(transactions), nil
+}
+
+// ListUserTransactions 按时间顺序返回用户所有目标在 since 到 until（不含）之间的星数流水
+func (s *MemoryStore) ListUserTransactions(ctx context.Context, ownerID int, since, until time.Time) ([]models.StarTransaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	transactions := []models.StarTransaction{}
+	for _, transaction := range s.transactions {
+		if transaction.GoalID == nil || s.goals[*transaction.GoalID].OwnerID != ownerID {
+			continue
+		}
+		if !transaction.CreatedAt.Before(since) && transaction.CreatedAt.Before(until) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+// appendTransaction 追加流水并回填ID和创建时间，关联目标的流水记录目标的所属用户，调用方需持有写锁
+func (s *MemoryStore) appendTransaction(transaction *models.StarTransaction) {
+	if transaction.GoalID != nil {
+		if ownerID := s.goals[*transaction.GoalID].OwnerID; ownerID != 0 {
+			transaction.OwnerID = &ownerID
+		}
+	}
+	s.nextID.transaction++
+	transaction.ID = s.nextID.transaction
+	transaction.CreatedAt = time.Now()
+	s.transactions = append(s.transactions, *transaction)
+}
+
+// goalStars 汇总目标的星数流水，调用方需持有读锁
+func (s *MemoryStore) goalStars(goalID int) int {
+	stars := 0
+	for _, transaction := range s.transactions {
+		if transaction.GoalID != nil && *transaction.GoalID == goalID {
+			stars += transaction.Amount
+		}
+	}
+	return stars
+}
+
+// userStars 汇总属于用户的所有星数流水，包括已删除目标留下的流水，调用方需持有读锁
+func (s *MemoryStore) userStars(ownerID int) int {
+	stars := 0
+	for _, transaction := range s.transactions {
+		if transaction.OwnerID != nil && *transaction.OwnerID == ownerID {
+			stars += transaction.Amount
+		}
+	}
+	return stars
+}
+
+// completeIfTargetReached 目标星数已经达到时，将进行中或已暂停的目标标记为已完成，调用方需持有写锁
+func (s *MemoryStore) completeIfTargetReached(goalID int) {
+	goal := s.goals[goalID]
+	goal.Stars = s.goalStars(goalID)
+	if !goal.TargetReached() || (goal.Status != models.GoalStatusActive && goal.Status != models.GoalStatusPaused) {
+		return
+	}
+	now := time.Now()
+	goal.Status = models.GoalStatusCompleted
+	if goal.CompletedAt == nil {
+		goal.CompletedAt = &now
+	}
+	goal.UpdatedAt = now
+	s.goals[goalID] = goal
+}
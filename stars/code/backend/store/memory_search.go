@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"starpool/search"
+	"strings"
+)
+
+// SearchDocuments 获取用户的目标和评论中包含任一检索词的候选文档
+func (s *MemoryStore) SearchDocuments(ctx context.Context, ownerID int, terms []string, limit int) ([]search.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var goals, comments []search.Document
+	for _, goal := range s.goals {
+		if goal.OwnerID == ownerID && containsAnyTerm(terms, goal.Title, goal.Description) {
+			goals = append(goals, search.Document{
+				Type: search.DocumentGoal, ID: goal.ID, GoalID: goal.ID, GoalTitle: goal.Title,
+				Title: goal.Title, Body: goal.Description,
+			})
+		}
+	}
+	for _, comment := range s.comments {
+		goal := s.goals[comment.GoalID]
+		if goal.OwnerID == ownerID && containsAnyTerm(terms, comment.Content) {
+			comments = append(comments, search.Document{
+				Type: search.DocumentComment, ID: comment.ID, GoalID: goal.ID, GoalTitle: goal.Title,
+				Body: comment.Content,
+			})
+		}
+	}
+	return append(newestDocuments(goals, limit), newestDocuments(comments, limit)...), nil
+}
+
+// containsAnyTerm 判断任一文本是否包含任一检索词（不区分大小写）
+func containsAnyTerm(terms []string, texts ...string) bool {
+	for _, text := range texts {
+		text = strings.ToLower(text)
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newestDocuments 按ID倒序返回最多 limit 个文档，与SQL实现的候选范围一致
+func newestDocuments(documents []search.Document, limit int) []search.Document {
+	sort.Slice(documents, func(i, j int) bool { return documents[i].ID > documents[j].ID })
+	if len(documents) > limit {
+		documents = documents[:limit]
+	}
+	return documents
+}
@@ -0,0 +1,606 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"starpool/models"
+	"sync"
+	"time"
+)
+
+// MemoryStore 基于内存的存储实现，用于测试和演示，进程退出后数据即丢失
+type MemoryStore struct {
+	mu           sync.RWMutex
+	goals        map[int]models.StarGoal
+	ratings      map[int]models.DailyRating
+	comments     map[int]models.Comment
+	commentEdits map[int][]models.CommentEdit // 评论ID -> 编辑历史
+	transactions []models.StarTransaction
+	users        map[int]models.User
+	achievements []models.Achievement
+	wishes       map[int]models.Wish
+	redemptions  map[int]models.Redemption
+	tags         map[int]models.Tag
+	goalTags     map[int][]int // 目标ID -> 标签ID
+	categories   map[int]models.Category
+	nextID       struct{ goal, rating, comment, commentEdit, transaction, user, achievement, wish, redemption, tag, category int }
+}
+
+// NewMemoryStore 创建一个空的 MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		goals:        make(map[int]models.StarGoal),
+		ratings:      make(map[int]models.DailyRating),
+		comments:     make(map[int]models.Comment),
+		commentEdits: make(map[int][]models.CommentEdit),
+		users:        make(map[int]models.User),
+		wishes:       make(map[int]models.Wish),
+		redemptions:  make(map[int]models.Redemption),
+		tags:         make(map[int]models.Tag),
+		goalTags:     make(map[int][]int),
+		categories:   make(map[int]models.Category),
+	}
+}
+
+// CreateGoal 创建新目标，类别不存在时自动创建，初始星数不为0时记录一笔调整流水，初始星数已达到目标星数时目标直接完成
+func (s *MemoryStore) CreateGoal(ctx context.Context, goal *models.StarGoal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID.goal++
+	now := time.Now()
+	goal.ID = s.nextID.goal
+	goal.Status = models.GoalStatusActive
+	goal.CompletedAt, goal.ArchivedAt = nil, nil
+	goal.CreatedAt = now
+	goal.UpdatedAt = now
+	s.ensureCategory(goal.OwnerID, goal.Category)
+	s.saveGoalTags(goal.ID, goal.OwnerID, goal.Tags)
+	s.goals[goal.ID] = *goal
+
+	if goal.Stars != 0 {
+		s.appendTransaction(&models.StarTransaction{
+			GoalID: &goal.ID,
+			Type:   models.StarTransactionAdjust,
+			Amount: goal.Stars,
+			Reason: "创建目标时的初始星数",
+			Source: models.StarSourceManual,
+		})
+		s.completeIfTargetReached(goal.ID)
+	}
+	*goal = s.goals[goal.ID]
+	goal.Stars = s.goalStars(goal.ID)
+	goal.Tags = s.goalTagNames(goal.ID)
+	goal.ComputeProgress(models.DateOf(now))
+	return nil
+}
+
+// GetGoal 根据ID获取单个目标
+func (s *MemoryStore) GetGoal(ctx context.Context, id int) (*models.StarGoal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	goal, ok := s.goals[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	goal.Stars = s.goalStars(id)
+	goal.Tags = s.goalTagNames(id)
+	goal.ComputeProgress(models.DateOf(time.Now()))
+	return &goal, nil
+}
+
+// UpdateGoal 更新目标，星数只能通过流水变动，不在此更新
+// 调低目标星数使其已经达到时，目标自动完成；父目标不能是目标自身或其子孙目标
+func (s *MemoryStore) UpdateGoal(ctx context.Context, goal *models.StarGoal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.goals[goal.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for current := goal.ParentID; current != nil; {
+		if *current == goal.ID {
+			return ErrGoalCycle
+		}
+		parent, ok := s.goals[*current]
+		if !ok {
+			return ErrNotFound
+		}
+		current = parent.ParentID
+	}
+	existing.Title = goal.Title
+	existing.Description = goal.Description
+	existing.ParentID = goal.ParentID
+	existing.Category = goal.Category
+	existing.TargetStars = goal.TargetStars
+	existing.DueDate = goal.DueDate
+	existing.Schedule = goal.Schedule
+	existing.UpdatedAt = time.Now()
+	s.ensureCategory(existing.OwnerID, goal.Category)
+	goal.Tags = s.saveGoalTags(goal.ID, existing.OwnerID, goal.Tags)
+	s.goals[goal.ID] = existing
+	s.completeIfTargetReached(goal.ID)
+	return nil
+}
+
+// UpdateGoalStatus 变更目标状态，仅当目标当前为 from 状态时生效
+func (s *MemoryStore) UpdateGoalStatus(ctx context.Context, id int, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, ok := s.goals[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if goal.Status != from {
+		return ErrConflict
+	}
+
+	now := time.Now()
+	switch to {
+	case models.GoalStatusCompleted:
+		if goal.CompletedAt == nil {
+			goal.CompletedAt = &now
+		}
+	case models.GoalStatusActive, models.GoalStatusPaused:
+		goal.CompletedAt = nil
+	}
+	goal.ArchivedAt = nil
+	if to == models.GoalStatusArchived {
+		goal.ArchivedAt = &now
+	}
+	goal.Status = to
+	goal.UpdatedAt = now
+	s.goals[id] = goal
+	return nil
+}
+
+// DeleteGoal 删除目标，并级联删除其评分和评论，星数流水保留但不再关联目标
+// cascade 为 true 时一并删除所有子孙目标，否则将子目标转移到被删除目标的父目标下
+func (s *MemoryStore) DeleteGoal(ctx context.Context, id int, cascade bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, ok := s.goals[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if cascade {
+		for _, descendant := range s.descendants(id) {
+			s.deleteGoal(descendant.ID)
+		}
+	} else {
+		for childID, child := range s.goals {
+			if child.ParentID != nil && *child.ParentID == id {
+				child.ParentID = goal.ParentID
+				child.UpdatedAt = time.Now()
+				s.goals[childID] = child
+			}
+		}
+	}
+	s.deleteGoal(id)
+	return nil
+}
+
+// deleteGoal 删除目标及其评分和评论，并将其星数流水的目标ID置空，调用方需持有写锁
+func (s *MemoryStore) deleteGoal(id int) {
+	delete(s.goals, id)
+	delete(s.goalTags, id)
+	for ratingID, rating := range s.ratings {
+		if rating.GoalID == id {
+			delete(s.ratings, ratingID)
+		}
+	}
+	for commentID, comment := range s.comments {
+		if comment.GoalID == id {
+			delete(s.comments, commentID)
+			delete(s.commentEdits, commentID)
+		}
+	}
+	for i, transaction := range s.transactions {
+		if transaction.GoalID != nil && *transaction.GoalID == id {
+			s.transactions[i].GoalID = nil
+		}
+	}
+}
+
+// TotalStars 获取用户的星数余额，即其目标（包括已删除的目标）所有星数流水与兑换心愿等用户流水的总和
+func (s *MemoryStore) TotalStars(ctx context.Context, ownerID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.userStars(ownerID), nil
+}
+
+// SaveDailyRating 插入或更新每日评分记录，并记录对应的星数流水，调低评分不能使用户的星数余额为负
+func (s *MemoryStore) SaveDailyRating(ctx context.Context, rating *models.DailyRating, merge RatingMerge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, ok := s.goals[rating.GoalID]
+	if !ok {
+		return ErrNotFound
+	}
+	if goal.Status != models.GoalStatusActive {
+		return ErrGoalNotActive
+	}
+
+	now := time.Now()
+	rating.CreatedAt = now
+	rating.ID = 0
+	previous, note, mood := 0, "", ""
+	for id, existing := range s.ratings {
+		if existing.GoalID == rating.GoalID && existing.Date.Equal(rating.Date.Time) {
+			rating.ID = id
+			previous, note, mood = existing.Rating, existing.Note, existing.Mood
+			break
+		}
+	}
+	if merge.KeepNote {
+		rating.Note = note
+	}
+	if merge.KeepMood {
+		rating.Mood = mood
+	}
+
+	transaction := &models.StarTransaction{GoalID: &goal.ID, Source: models.StarSourceDailyRating}
+	if rating.ID == 0 {
+		s.nextID.rating++
+		rating.ID = s.nextID.rating
+		transaction.Type = models.StarTransactionEarn
+		transaction.Amount = rating.Rating
+		transaction.Reason = "每日评分"
+	} else {
+		transaction.Type = models.StarTransactionAdjust
+		transaction.Amount = rating.Rating - previous
+		transaction.Reason = fmt.Sprintf("修改评分 %d → %d", previous, rating.Rating)
+		if transaction.Amount < 0 && goal.OwnerID != 0 && s.userStars(goal.OwnerID)+transaction.Amount < 0 {
+			return ErrInsufficientStars
+		}
+	}
+	s.ratings[rating.ID] = *rating
+
+	goal.UpdatedAt = now
+	s.goals[goal.ID] = goal
+
+	if transaction.Amount != 0 {
+		transaction.SourceID = &rating.ID
+		s.appendTransaction(transaction)
+		s.completeIfTargetReached(goal.ID)
+	}
+	return nil
+}
+
+// ListDailyRatings 获取目标在 from 到 to（含）之间的每日评分记录
+func (s *MemoryStore) ListDailyRatings(ctx context.Context, goalID int, from, to models.Date) ([]models.DailyRating, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings := []models.DailyRating{}
+	for _, rating := range s.ratings {
+		if rating.GoalID == goalID && !rating.Date.Before(from.Time) && !rating.Date.After(to.Time) {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool { return ratings[i].Date.Before(ratings[j].Date.Time) })
+	return ratings, nil
+}
+
+// ListRatingDates 获取各目标在 from 及之后评分不低于 minRating 的日期
+func (s *MemoryStore) ListRatingDates(ctx context.Context, goalIDs []int, minRating int, from models.Date) (map[int][]models.Date, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(goalIDs))
+	for _, id := range goalIDs {
+		wanted[id] = true
+	}
+	var ratings []models.DailyRating
+	for _, rating := range s.ratings {
+		if wanted[rating.GoalID] && rating.Rating >= minRating && !rating.Date.Before(from.Time) {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool { return ratings[i].Date.Before(ratings[j].Date.Time) })
+
+	dates := make(map[int][]models.Date)
+	for _, rating := range ratings {
+		dates[rating.GoalID] = append(dates[rating.GoalID], rating.Date)
+	}
+	return dates, nil
+}
+
+// ListUserRatings 获取用户所有目标在 from 到 to（含）之间的每日评分
+func (s *MemoryStore) ListUserRatings(ctx context.Context, ownerID int, from, to models.Date) ([]models.DailyRating, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings := []models.DailyRating{}
+	for _, rating := range s.ratings {
+		if s.goals[rating.GoalID].OwnerID == ownerID && !rating.Date.Before(from.Time) && !rating.Date.After(to.Time) {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		if !ratings[i].Date.Equal(ratings[j].Date.Time) {
+			return ratings[i].Date.Before(ratings[j].Date.Time)
+		}
+		return ratings[i].GoalID < ratings[j].GoalID
+	})
+	return ratings, nil
+}
+
+// SumUserRatings 汇总用户所有目标中评分不低于 minRating 的评分次数和星数
+func (s *MemoryStore) SumUserRatings(ctx context.Context, ownerID, minRating int) (models.RatingTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total models.RatingTotal
+	for _, rating := range s.ratings {
+		if s.goals[rating.GoalID].OwnerID == ownerID && rating.Rating >= minRating {
+			total.Count++
+			total.Stars += rating.Rating
+		}
+	}
+	return total, nil
+}
+
+// SumRatingsByDay 按日期汇总用户满足条件的评分次数和星数
+func (s *MemoryStore) SumRatingsByDay(ctx context.Context, query HeatmapQuery) ([]models.DayTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byDate := make(map[string]*models.DayTotal)
+	for _, rating := range s.ratings {
+		goal := s.goals[rating.GoalID]
+		if goal.OwnerID != query.OwnerID || rating.Date.Before(query.From.Time) || rating.Date.After(query.To.Time) {
+			continue
+		}
+		if (query.GoalID != 0 && goal.ID != query.GoalID) || (query.Category != "" && goal.Category != query.Category) {
+			continue
+		}
+		total, ok := byDate[rating.Date.String()]
+		if !ok {
+			total = &models.DayTotal{Date: rating.Date}
+			byDate[rating.Date.String()] = total
+		}
+		total.Count++
+		total.Stars += rating.Rating
+	}
+
+	totals := make([]models.DayTotal, 0, len(byDate))
+	for _, total := range byDate {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date.Before(totals[j].Date.Time) })
+	return totals, nil
+}
+
+// SumRatingsForLeaderboard 按用户、目标或类别汇总评分，排除退出排行榜的用户和未分类的目标
+func (s *MemoryStore) SumRatingsForLeaderboard(ctx context.Context, query LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct {
+		userID   int
+		goalID   int
+		category string
+	}
+	totals := make(map[key]*models.LeaderboardEntry)
+	for _, rating := range s.ratings {
+		goal := s.goals[rating.GoalID]
+		user, ok := s.users[goal.OwnerID]
+		if !ok || user.LeaderboardOptOut {
+			continue
+		}
+		if (query.From != nil && rating.Date.Before(query.From.Time)) || (query.To != nil && rating.Date.After(query.To.Time)) {
+			continue
+		}
+		k := key{userID: user.ID}
+		switch query.By {
+		case models.LeaderboardGoals:
+			k.goalID = goal.ID
+		case models.LeaderboardCategories:
+			if goal.Category == "" {
+				continue
+			}
+			k.category = goal.Category
+		}
+		entry, ok := totals[k]
+		if !ok {
+			entry = &models.LeaderboardEntry{UserID: user.ID, Username: user.Username, GoalID: k.goalID, Category: k.category}
+			if k.goalID != 0 {
+				entry.Title = goal.Title
+			}
+			totals[k] = entry
+		}
+		entry.Stars += rating.Rating
+		entry.Ratings++
+	}
+
+	entries := make([]models.LeaderboardEntry, 0, len(totals))
+	for _, entry := range totals {
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// ListJournal 获取用户所有目标带备注的每日评分，按日期升序
+func (s *MemoryStore) ListJournal(ctx context.Context, ownerID int, from, to *models.Date) ([]models.JournalEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := []models.JournalEntry{}
+	for _, rating := range s.ratings {
+		goal := s.goals[rating.GoalID]
+		if goal.OwnerID != ownerID || rating.Note == "" {
+			continue
+		}
+		if (from != nil && rating.Date.Before(from.Time)) || (to != nil && rating.Date.After(to.Time)) {
+			continue
+		}
+		entries = append(entries, models.JournalEntry{
+			RatingID:  rating.ID,
+			GoalID:    goal.ID,
+			GoalTitle: goal.Title,
+			Date:      rating.Date,
+			Rating:    rating.Rating,
+			Mood:      rating.Mood,
+			Note:      rating.Note,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date.Time) {
+			return entries[i].Date.Before(entries[j].Date.Time)
+		}
+		return entries[i].GoalID < entries[j].GoalID
+	})
+	return entries, nil
+}
+
+// CreateComment 创建新评论
+func (s *MemoryStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.goals[comment.GoalID]; !ok {
+		return ErrNotFound
+	}
+	s.nextID.comment++
+	comment.ID = s.nextID.comment
+	comment.CreatedAt = time.Now()
+	comment.EditedAt, comment.DeletedAt = nil, nil
+	s.comments[comment.ID] = *comment
+	return nil
+}
+
+// GetComment 获取属于指定目标的评论
+func (s *MemoryStore) GetComment(ctx context.Context, goalID int, id int) (*models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok || comment.GoalID != goalID {
+		return nil, ErrNotFound
+	}
+	return &comment, nil
+}
+
+// ListComments 获取目标的所有评论
+func (s *MemoryStore) ListComments(ctx context.Context, goalID int) ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		if comment.GoalID == goalID {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments, nil
+}
+
+// UpdateComment 保存评论的旧内容到编辑历史并修改内容
+func (s *MemoryStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.comments[comment.ID]
+	if !ok || existing.GoalID != comment.GoalID {
+		return ErrNotFound
+	}
+	if existing.DeletedAt != nil {
+		return ErrCommentDeleted
+	}
+
+	now := time.Now()
+	s.nextID.commentEdit++
+	s.commentEdits[comment.ID] = append(s.commentEdits[comment.ID], models.CommentEdit{
+		ID:        s.nextID.commentEdit,
+		CommentID: comment.ID,
+		Content:   existing.Content,
+		EditedAt:  now,
+	})
+	existing.Content = comment.Content
+	existing.EditedAt = &now
+	s.comments[comment.ID] = existing
+	comment.EditedAt = &now
+	return nil
+}
+
+// DeleteComment 删除评论，有回复的评论保留为占位
+func (s *MemoryStore) DeleteComment(ctx context.Context, goalID int, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[id]
+	if !ok || comment.GoalID != goalID {
+		return false, ErrNotFound
+	}
+	if comment.DeletedAt != nil {
+		return false, ErrCommentDeleted
+	}
+	if s.hasReplies(id) {
+		now := time.Now()
+		comment.Content = ""
+		comment.DeletedAt = &now
+		s.comments[id] = comment
+		delete(s.commentEdits, id)
+		return true, nil
+	}
+	delete(s.comments, id)
+	delete(s.commentEdits, id)
+
+	// 依次删除不再有回复的已删除祖先评论
+	for parentID := comment.ParentID; parentID != nil; {
+		parent := s.comments[*parentID]
+		if parent.DeletedAt == nil || s.hasReplies(parent.ID) {
+			break
+		}
+		delete(s.comments, parent.ID)
+		parentID = parent.ParentID
+	}
+	return false, nil
+}
+
+// ListCommentEdits 获取评论的编辑历史
+func (s *MemoryStore) ListCommentEdits(ctx context.Context, commentID int) ([]models.CommentEdit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.CommentEdit{}, s.commentEdits[commentID]...), nil
+}
+
+// hasReplies 判断评论是否有回复，调用方需持有读锁
+func (s *MemoryStore) hasReplies(id int) bool {
+	for _, comment := range s.comments {
+		if comment.ParentID != nil && *comment.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// filterGoals 按ID顺序返回满足条件的目标
+func (s *MemoryStore) filterGoals(match func(models.StarGoal) bool) []models.StarGoal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	today := models.DateOf(time.Now())
+	var goals []models.StarGoal
+	for _, goal := range s.goals {
+		goal.Stars = s.goalStars(goal.ID)
+		goal.Tags = s.goalTagNames(goal.ID)
+		goal.ComputeProgress(today)
+		if match(goal) {
+			goals = append(goals, goal)
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+	return goals
+}
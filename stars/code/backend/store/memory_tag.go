@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"starpool/models"
+	"time"
+)
+
+// ListTags 获取用户的所有标签及使用各标签的目标数
+func (s *MemoryStore) ListTags(ctx context.Context, ownerID int) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := []models.Tag{}
+	for _, tag := range s.tags {
+		if tag.OwnerID == ownerID {
+			tag.GoalCount = s.tagGoalCount(tag.ID)
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// GetTag 根据ID获取标签
+func (s *MemoryStore) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tag, ok := s.tags[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	tag.GoalCount = s.tagGoalCount(id)
+	return &tag, nil
+}
+
+// RenameTag 重命名标签，同一用户已有同名标签时返回 ErrDuplicate
+func (s *MemoryStore) RenameTag(ctx context.Context, id int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing, ok := s.findTag(tag.OwnerID, name); ok && existing.ID != id {
+		return ErrDuplicate
+	}
+	tag.Name = name
+	s.tags[id] = tag
+	return nil
+}
+
+// MergeTags 将源标签合并到目标标签，已同时使用两个标签的目标只保留一条关联
+func (s *MemoryStore) MergeTags(ctx context.Context, sourceIDs []int, targetID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tags[targetID]; !ok {
+		return ErrNotFound
+	}
+	for _, sourceID := range sourceIDs {
+		if _, ok := s.tags[sourceID]; !ok {
+			return ErrNotFound
+		}
+	}
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			continue
+		}
+		for goalID, tagIDs := range s.goalTags {
+			if slices.Contains(tagIDs, sourceID) && !slices.Contains(tagIDs, targetID) {
+				s.goalTags[goalID] = append(tagIDs, targetID)
+			}
+		}
+		s.deleteTag(sourceID)
+	}
+	return nil
+}
+
+// DeleteTag 删除标签及其与目标的关联
+func (s *MemoryStore) DeleteTag(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tags[id]; !ok {
+		return ErrNotFound
+	}
+	s.deleteTag(id)
+	return nil
+}
+
+// deleteTag 删除标签及其与目标的关联，调用方需持有写锁
+func (s *MemoryStore) deleteTag(id int) {
+	delete(s.tags, id)
+	for goalID, tagIDs := range s.goalTags {
+		s.goalTags[goalID] = slices.DeleteFunc(tagIDs, func(tagID int) bool { return tagID == id })
+	}
+}
+
+// saveGoalTags 将目标的标签替换为 names，不存在的标签自动创建，调用方需持有写锁
+// 返回规范化、去重并排序后的标签名
+func (s *MemoryStore) saveGoalTags(goalID, ownerID int, names []string) []string {
+	names = normalizeTags(names)
+	tagIDs := make([]int, 0, len(names))
+	for _, name := range names {
+		tag, ok := s.findTag(ownerID, name)
+		if !ok {
+			s.nextID.tag++
+			tag = models.Tag{ID: s.nextID.tag, OwnerID: ownerID, Name: name, CreatedAt: time.Now()}
+			s.tags[tag.ID] = tag
+		}
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	s.goalTags[goalID] = tagIDs
+	return names
+}
+
+// goalTagNames 返回目标按名称排序的标签名，调用方需持有读锁
+func (s *MemoryStore) goalTagNames(goalID int) []string {
+	names := []string{}
+	for _, tagID := range s.goalTags[goalID] {
+		names = append(names, s.tags[tagID].Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findTag 按名称查找用户的标签，调用方需持有读锁
+func (s *MemoryStore) findTag(ownerID int, name string) (models.Tag, bool) {
+	for _, tag := range s.tags {
+		if tag.OwnerID == ownerID && tag.Name == name {
+			return tag, true
+		}
+	}
+	return models.Tag{}, false
+}
+
+// tagGoalCount 返回使用该标签的目标数，调用方需持有读锁
+func (s *MemoryStore) tagGoalCount(tagID int) int {
+	count := 0
+	for _, tagIDs := range s.goalTags {
+		if slices.Contains(tagIDs, tagID) {
+			count++
+		}
+	}
+	return count
+}
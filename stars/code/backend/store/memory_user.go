@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"starpool/models"
+	"strings"
+	"time"
+)
+
+// CreateUser 创建新用户，第一个用户同时认领所有尚无所属用户的目标、星数流水、标签和类别
+func (s *MemoryStore) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findUser(user.Username); ok {
+		return ErrDuplicate
+	}
+	s.nextID.user++
+	user.ID = s.nextID.user
+	user.CreatedAt = time.Now()
+	s.users[user.ID] = *user
+
+	if len(s.users) == 1 {
+		for id, goal := range s.goals {
+			if goal.OwnerID == 0 {
+				goal.OwnerID = user.ID
+				s.goals[id] = goal
+			}
+		}
+		for i, transaction := range s.transactions {
+			if transaction.OwnerID == nil {
+				ownerID := user.ID
+				s.transactions[i].OwnerID = &ownerID
+			}
+		}
+		for id, tag := range s.tags {
+			if tag.OwnerID == 0 {
+				tag.OwnerID = user.ID
+				s.tags[id] = tag
+			}
+		}
+		for id, category := range s.categories {
+			if category.OwnerID == 0 {
+				category.OwnerID = user.ID
+				s.categories[id] = category
+			}
+		}
+	}
+	return nil
+}
+
+// GetUser 根据ID获取用户
+func (s *MemoryStore) GetUser(ctx context.Context, id int) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// GetUserByUsername 根据用户名获取用户
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.findUser(username)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// UpdateUserSettings 保存用户的时区和排行榜设置
+func (s *MemoryStore) UpdateUserSettings(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Timezone = user.Timezone
+	existing.LeaderboardOptOut = user.LeaderboardOptOut
+	s.users[user.ID] = existing
+	return nil
+}
+
+// findUser 按用户名（不区分大小写）查找用户，调用方需持有读锁
+func (s *MemoryStore) findUser(username string) (models.User, bool) {
+	for _, user := range s.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, true
+		}
+	}
+	return models.User{}, false
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"starpool/models"
+	"time"
+)
+
+// ListWishes 获取用户的所有心愿
+func (s *MemoryStore) ListWishes(ctx context.Context, ownerID int) ([]models.Wish, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wishes := []models.Wish{}
+	for _, wish := range s.wishes {
+		if wish.OwnerID == ownerID {
+			wishes = append(wishes, s.withApprover(wish))
+		}
+	}
+	sort.Slice(wishes, func(i, j int) bool { return wishes[i].ID < wishes[j].ID })
+	return wishes, nil
+}
+
+// GetWish 根据ID获取心愿
+func (s *MemoryStore) GetWish(ctx context.Context, id int) (*models.Wish, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wish, ok := s.wishes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	wish = s.withApprover(wish)
+	return &wish, nil
+}
+
+// CreateWish 创建新心愿
+func (s *MemoryStore) CreateWish(ctx context.Context, wish *models.Wish) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID.wish++
+	now := time.Now()
+	wish.ID = s.nextID.wish
+	wish.CreatedAt = now
+	wish.UpdatedAt = now
+	s.wishes[wish.ID] = *wish
+	return nil
+}
+
+// UpdateWish 更新心愿
+func (s *MemoryStore) UpdateWish(ctx context.Context, wish *models.Wish) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.wishes[wish.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Title = wish.Title
+	existing.Description = wish.Description
+	existing.Price = wish.Price
+	existing.ApproverID = wish.ApproverID
+	existing.UpdatedAt = time.Now()
+	s.wishes[wish.ID] = existing
+	return nil
+}
+
+// DeleteWish 删除心愿，兑换记录的心愿ID置空
+func (s *MemoryStore) DeleteWish(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.wishes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.wishes, id)
+	for redemptionID, redemption := range s.redemptions {
+		if redemption.WishID != nil && *redemption.WishID == id {
+			redemption.WishID = nil
+			s.redemptions[redemptionID] = redemption
+		}
+	}
+	return nil
+}
+
+// RedeemWish 检查余额、记录兑换并追加消费流水
+func (s *MemoryStore) RedeemWish(ctx context.Context, wishID, userID int) (*models.Redemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wish, ok := s.wishes[wishID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return nil, ErrNotFound
+	}
+	if s.userStars(userID) < wish.Price {
+		return nil, ErrInsufficientStars
+	}
+
+	// 无需审批的兑换直接兑现
+	s.nextID.redemption++
+	now := time.Now()
+	redemption := models.Redemption{
+		ID:         s.nextID.redemption,
+		WishID:     &wish.ID,
+		UserID:     userID,
+		Title:      wish.Title,
+		Price:      wish.Price,
+		Status:     models.RedemptionGranted,
+		ApproverID: wish.ApproverID,
+		CreatedAt:  now,
+		DecidedAt:  &now,
+	}
+	if wish.ApproverID != nil {
+		redemption.Status, redemption.DecidedAt = models.RedemptionPending, nil
+	}
+	s.redemptions[redemption.ID] = redemption
+	s.appendTransaction(&models.StarTransaction{
+		OwnerID:  &userID,
+		Type:     models.StarTransactionSpend,
+		Amount:   -wish.Price,
+		Reason:   "兑换心愿：" + wish.Title,
+		Source:   models.StarSourceRedemption,
+		SourceID: &redemption.ID,
+	})
+
+	redemption = s.withUsernames(redemption)
+	return &redemption, nil
+}
+
+// ListRedemptions 获取满足条件的兑换记录，按兑换时间倒序
+func (s *MemoryStore) ListRedemptions(ctx context.Context, query RedemptionQuery) ([]models.Redemption, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	redemptions := []models.Redemption{}
+	for _, redemption := range s.redemptions {
+		if query.UserID != 0 && redemption.UserID != query.UserID {
+			continue
+		}
+		if query.ApproverID != 0 && (redemption.ApproverID == nil || *redemption.ApproverID != query.ApproverID) {
+			continue
+		}
+		if query.Status != "" && redemption.Status != query.Status {
+			continue
+		}
+		redemptions = append(redemptions, s.withUsernames(redemption))
+	}
+	sort.Slice(redemptions, func(i, j int) bool { return redemptions[i].ID > redemptions[j].ID })
+	return redemptions, nil
+}
+
+// GetRedemption 根据ID获取兑换记录
+func (s *MemoryStore) GetRedemption(ctx context.Context, id int) (*models.Redemption, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	redemption, ok := s.redemptions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	redemption = s.withUsernames(redemption)
+	return &redemption, nil
+}
+
+// DecideRedemption 同意或拒绝等待审批的兑换，拒绝时追加退还星数的调整流水
+func (s *MemoryStore) DecideRedemption(ctx context.Context, id int, approve bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	redemption, ok := s.redemptions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if redemption.Status != models.RedemptionPending {
+		return ErrRedemptionDecided
+	}
+
+	now := time.Now()
+	redemption.Status = models.RedemptionGranted
+	if !approve {
+		redemption.Status = models.RedemptionRejected
+	}
+	redemption.DecidedAt = &now
+	s.redemptions[id] = redemption
+	if approve {
+		return nil
+	}
+	s.appendTransaction(&models.StarTransaction{
+		OwnerID:  &redemption.UserID,
+		Type:     models.StarTransactionAdjust,
+		Amount:   redemption.Price,
+		Reason:   "心愿兑换被拒绝，退还星数：" + redemption.Title,
+		Source:   models.StarSourceRedemption,
+		SourceID: &redemption.ID,
+	})
+	return nil
+}
+
+// withApprover 填充心愿审批人的用户名，调用方需持有读锁
+func (s *MemoryStore) withApprover(wish models.Wish) models.Wish {
+	wish.Approver = ""
+	if wish.ApproverID != nil {
+		wish.Approver = s.users[*wish.ApproverID].Username
+	}
+	return wish
+}
+
+// withUsernames 填充兑换记录中兑换用户和审批人的用户名，调用方需持有读锁
+func (s *MemoryStore) withUsernames(redemption models.Redemption) models.Redemption {
+	redemption.Username = s.users[redemption.UserID].Username
+	redemption.Approver = ""
+	if redemption.ApproverID != nil {
+		redemption.Approver = s.users[*redemption.ApproverID].Username
+	}
+	return redemption
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"starpool/models"
+	"time"
+)
+
+// AddAchievement 记录用户获得的成就
+func (s *SQLStore) AddAchievement(ctx context.Context, achievement *models.Achievement) error {
+	query := `INSERT INTO achievements (user_id, badge, earned_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+	result, err := s.db.ExecContext(ctx, query, achievement.UserID, achievement.Badge)
+	if err != nil {
+		return duplicateError(err)
+	}
+
+	// 获取插入记录的ID
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	achievement.ID = int(id)
+	achievement.EarnedAt = time.Now()
+	return nil
+}
+
+// ListAchievements 获取用户获得的所有成就
+func (s *SQLStore) ListAchievements(ctx context.Context, userID int) ([]models.Achievement, error) {
+	query := `SELECT id, user_id, badge, earned_at FROM achievements WHERE user_id = ? ORDER BY earned_at, id`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	achievements := []models.Achievement{}
+	for rows.Next() {
+		var achievement models.Achievement
+		if err := rows.Scan(&achievement.ID, &achievement.UserID, &achievement.Badge, &achievement.EarnedAt); err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+	return achievements, rows.Err()
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"starpool/models"
+	"time"
+)
+
+// categoryColumns 查询类别时选取的列，顺序与 categoryFields 一致
+const categoryColumns = `c.id, COALESCE(c.owner_id, 0), c.name, c.color, c.icon, c.sort_order, c.weekly_star_budget, c.created_at, c.updated_at`
+
+// categoryFields 返回与 categoryColumns 顺序一致的扫描目标
+func categoryFields(category *models.Category) []interface{} {
+	return []interface{}{
+		&category.ID, &category.OwnerID, &category.Name, &category.Color, &category.Icon,
+		&category.SortOrder, &category.WeeklyStarBudget, &category.CreatedAt, &category.UpdatedAt,
+	}
+}
+
+// ListCategories 获取用户的所有类别及其目标数、星数合计和 since 之后的评分情况
+func (s *SQLStore) ListCategories(ctx context.Context, ownerID int, since models.Date) ([]models.Category, error) {
+	query := `SELECT ` + categoryColumns + `,
+		(SELECT COUNT(*) FROM star_goals g WHERE g.owner_id = c.owner_id AND g.category = c.name),
+		(SELECT COALESCE(SUM(t.amount), 0) FROM star_transactions t JOIN star_goals g ON g.id = t.goal_id
+			WHERE g.owner_id = c.owner_id AND g.category = c.name)
+		FROM categories c WHERE c.owner_id = ? ORDER BY c.sort_order, c.name, c.id`
+	rows, err := s.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	byName := make(map[string]*models.Category)
+	for rows.Next() {
+		var category models.Category
+		fields := append(categoryFields(&category), &category.GoalCount, &category.TotalStars)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for i := range categories {
+		byName[categories[i].Name] = &categories[i]
+	}
+
+	// 按日期汇总在查询结果上完成
+	query = `SELECT g.category, r.date, r.rating FROM daily_ratings r JOIN star_goals g ON g.id = r.goal_id
+		WHERE g.owner_id = ? AND r.date >= ? ORDER BY r.date`
+	rows, err = s.db.QueryContext(ctx, query, ownerID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name sql.NullString
+		var date models.Date
+		var rating int
+		if err := rows.Scan(&name, &date, &rating); err != nil {
+			return nil, err
+		}
+		if category, ok := byName[name.String]; ok {
+			addCategoryActivity(category, date, rating)
+		}
+	}
+	return categories, rows.Err()
+}
+
+// GetCategory 根据ID获取类别
+func (s *SQLStore) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	var category models.Category
+	query := `SELECT ` + categoryColumns + ` FROM categories c WHERE c.id = ?`
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(categoryFields(&category)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CreateCategory 创建新类别
+func (s *SQLStore) CreateCategory(ctx context.Context, category *models.Category) error {
+	query := `INSERT INTO categories (owner_id, name, color, icon, sort_order, weekly_star_budget, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	result, err := s.db.ExecContext(ctx, query, category.OwnerID, category.Name, category.Color, category.Icon, category.SortOrder, category.WeeklyStarBudget)
+	if err != nil {
+		return duplicateError(err)
+	}
+
+	// 获取插入记录的ID
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	category.ID = int(id)
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	return nil
+}
+
+// UpdateCategory 更新类别，改名时同步修改该类别下目标的类别名
+func (s *SQLStore) UpdateCategory(ctx context.Context, category *models.Category) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var ownerID int
+		var name string
+		query := `SELECT COALESCE(owner_id, 0), name FROM categories WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, category.ID).Scan(&ownerID, &name); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		query = `UPDATE categories SET name = ?, color = ?, icon = ?, sort_order = ?, weekly_star_budget = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query, category.Name, category.Color, category.Icon, category.SortOrder, category.WeeklyStarBudget, category.ID)
+		if err != nil {
+			return duplicateError(err)
+		}
+		if name == category.Name {
+			return nil
+		}
+		query = `UPDATE star_goals SET category = ?, updated_at = CURRENT_TIMESTAMP WHERE owner_id = ? AND category = ?`
+		_, err = tx.ExecContext(ctx, query, category.Name, ownerID, name)
+		return err
+	})
+}
+
+// DeleteCategory 删除类别并清空该类别下目标的类别名
+func (s *SQLStore) DeleteCategory(ctx context.Context, id int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var ownerID int
+		var name string
+		query := `SELECT COALESCE(owner_id, 0), name FROM categories WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&ownerID, &name); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		query = `UPDATE star_goals SET category = '', updated_at = CURRENT_TIMESTAMP WHERE owner_id = ? AND category = ?`
+		if _, err := tx.ExecContext(ctx, query, ownerID, name); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = ?`, id)
+		return err
+	})
+}
+
+// ensureCategory 在事务中确保用户有名为 name 的类别，不存在时以默认设置创建
+func ensureCategory(ctx context.Context, tx *sql.Tx, ownerID int, name string) error {
+	if name == "" {
+		return nil
+	}
+	var id int
+	query := `SELECT id FROM categories WHERE owner_id = ? AND name = ?`
+	err := tx.QueryRowContext(ctx, query, ownerID, name).Scan(&id)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	query = `INSERT INTO categories (owner_id, name, created_at, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	_, err = tx.ExecContext(ctx, query, ownerID, name)
+	return duplicateError(err)
+}
+
+// addCategoryActivity 将一条评分计入类别当天的评分情况，评分需按日期升序传入
+func addCategoryActivity(category *models.Category, date models.Date, rating int) {
+	last := len(category.Activity) - 1
+	if last < 0 || !category.Activity[last].Date.Equal(date.Time) {
+		category.Activity = append(category.Activity, models.CategoryActivity{Date: date})
+		last++
+	}
+	category.Activity[last].Ratings++
+	category.Activity[last].Stars += rating
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"starpool/models"
+	"strings"
+	"time"
+)
+
+// goalSortColumns 排序字段对应的列，均为 goalColumns 子查询中的列名
+var goalSortColumns = map[string]string{
+	GoalSortStars:     "stars",
+	GoalSortCreatedAt: "created_at",
+	GoalSortUpdatedAt: "updated_at",
+	GoalSortTitle:     "title",
+}
+
+// ListGoals 按过滤、排序和分页条件获取用户的一页目标
+// 分页基于（排序值, ID）的键集，翻页期间插入或删除目标不会导致重复或遗漏
+func (s *SQLStore) ListGoals(ctx context.Context, query GoalQuery) (*GoalPage, error) {
+	column, ok := goalSortColumns[query.Sort]
+	if !ok {
+		column, query.Sort = goalSortColumns[GoalSortCreatedAt], GoalSortCreatedAt
+	}
+
+	// 星数是汇总列，包一层子查询后才能在 WHERE 和 ORDER BY 中使用
+	from := `FROM (SELECT ` + goalColumns + ` FROM star_goals g WHERE g.owner_id = ?) goals`
+	conditions := []string{"1 = 1"}
+	args := []interface{}{query.OwnerID}
+	if query.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, query.Category)
+	}
+	if len(query.Statuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(query.Statuses)), ", ")
+		conditions = append(conditions, "status IN ("+placeholders+")")
+		for _, status := range query.Statuses {
+			args = append(args, status)
+		}
+	}
+	if len(query.Tags) > 0 {
+		tags := normalizeTags(query.Tags)
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tags)), ", ")
+		subquery := `SELECT gt.goal_id FROM goal_tags gt JOIN tags t ON t.id = gt.tag_id WHERE t.name IN (` + placeholders + `)`
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+		if query.AllTags {
+			subquery += ` GROUP BY gt.goal_id HAVING COUNT(*) = ?`
+			args = append(args, len(tags))
+		}
+		conditions = append(conditions, "id IN ("+subquery+")")
+	}
+	if query.MinStars != nil {
+		conditions = append(conditions, "stars >= ?")
+		args = append(args, *query.MinStars)
+	}
+	if query.MaxStars != nil {
+		conditions = append(conditions, "stars <= ?")
+		args = append(args, *query.MaxStars)
+	}
+	if query.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, s.timeArg(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, s.timeArg(*query.CreatedTo))
+	}
+	if query.DueBefore != nil {
+		conditions = append(conditions, "due_date < ?")
+		args = append(args, *query.DueBefore)
+	}
+	if query.BelowTarget {
+		conditions = append(conditions, "(target_stars IS NULL OR stars < target_stars)")
+	}
+	where := ` WHERE ` + strings.Join(conditions, " AND ")
+
+	page := &GoalPage{Goals: []models.StarGoal{}}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) `+from+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if query.Cursor != "" {
+		anchor, err := decodeGoalCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		value := s.goalSortArg(anchor, query.Sort)
+		where += fmt.Sprintf(" AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison)
+		args = append(args, value, value, anchor.ID)
+	}
+
+	statement := `SELECT * ` + from + where + fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", column, direction)
+	if query.Limit > 0 {
+		// 多取一条用于判断是否还有下一页
+		statement += " LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+	goals, err := s.queryGoals(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit > 0 && len(goals) > query.Limit {
+		goals = goals[:query.Limit]
+		page.NextCursor = encodeGoalCursor(goals[len(goals)-1], query.Sort)
+	}
+	if goals != nil {
+		page.Goals = goals
+	}
+	return page, nil
+}
+
+// goalSortArg 返回游标位置在排序字段上的查询参数
+func (s *SQLStore) goalSortArg(anchor models.StarGoal, sort string) interface{} {
+	switch sort {
+	case GoalSortStars:
+		return anchor.Stars
+	case GoalSortUpdatedAt:
+		return s.timeArg(anchor.UpdatedAt)
+	case GoalSortTitle:
+		return anchor.Title
+	default:
+		return s.timeArg(anchor.CreatedAt)
+	}
+}
+
+// timeArg 将时间转换为当前方言下可与时间戳列比较的查询参数
+// SQLite 以 "YYYY-MM-DD HH:MM:SS" 文本保存 CURRENT_TIMESTAMP，需按同样格式传入 UTC 时间
+func (s *SQLStore) timeArg(t time.Time) interface{} {
+	if s.dialect == DialectSQLite {
+		return t.UTC().Format("2006-01-02 15:04:05")
+	}
+	return t
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"starpool/models"
+	"strings"
+)
+
+// queryer 是 *sql.DB 和 *sql.Tx 共有的查询方法
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// ListGoalDescendants 获取目标的所有子孙目标
+func (s *SQLStore) ListGoalDescendants(ctx context.Context, id int) ([]models.StarGoal, error) {
+	ids, err := descendantIDs(ctx, s.db, id)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	query, args := inClause(`SELECT `+goalColumns+` FROM star_goals g WHERE g.id IN `, ids)
+	return s.queryGoals(ctx, query, args...)
+}
+
+// checkGoalParent 检查将目标的父目标设为 parentID 是否会形成循环，
+// 沿父目标向上查找，遇到目标自身即说明父目标是目标自身或其子孙目标
+func (s *SQLStore) checkGoalParent(ctx context.Context, tx *sql.Tx, goalID int, parentID *int) error {
+	for current := parentID; current != nil; {
+		if *current == goalID {
+			return ErrGoalCycle
+		}
+		var next *int
+		query := `SELECT parent_id FROM star_goals WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, *current).Scan(&next); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		current = next
+	}
+	return nil
+}
+
+// descendantIDs 逐层查询目标的所有子孙目标ID
+// 不使用递归 CTE，以兼容 MySQL 5.7
+func descendantIDs(ctx context.Context, q queryer, id int) ([]int, error) {
+	var descendants []int
+	level := []int{id}
+	for len(level) > 0 {
+		query, args := inClause(`SELECT id FROM star_goals WHERE parent_id IN `, level)
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		var next []int
+		for rows.Next() {
+			var child int
+			if err := rows.Scan(&child); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			next = append(next, child)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, next...)
+		level = next
+	}
+	return descendants, nil
+}
+
+// inClause 在查询后追加 "(?, ?, ...)" 占位符，并返回对应的参数
+func inClause(query string, ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return query + "(" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")", args
+}
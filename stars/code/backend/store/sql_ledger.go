@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"starpool/models"
+	"time"
+)
+
+// AddStarTransaction 追加一笔星数流水，关联目标的流水记录目标的所属用户，并回填该笔流水后目标的星数余额，关联的目标不存在时返回 ErrNotFound，
+// 扣减星数使目标星数或所属用户的星数余额为负时返回 ErrInsufficientStars
+// 关联目标的星数因此达到目标星数时，目标自动完成
+func (s *SQLStore) AddStarTransaction(ctx context.Context, transaction *models.StarTransaction) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if transaction.GoalID == nil {
+			return insertStarTransaction(ctx, tx, transaction)
+		}
+
+		goalID := *transaction.GoalID
+		ownerID, err := s.lockGoalOwner(ctx, tx, goalID)
+		if err != nil {
+			return err
+		}
+		transaction.OwnerID = ownerID
+		if err := insertStarTransaction(ctx, tx, transaction); err != nil {
+			return err
+		}
+
+		// 计算该笔流水后的余额，扣减星数时目标和用户的余额都不能为负
+		query := `SELECT COALESCE(SUM(amount), 0) FROM star_transactions WHERE goal_id = ?`
+		if err := tx.QueryRowContext(ctx, query, goalID).Scan(&transaction.Balance); err != nil {
+			return err
+		}
+		if transaction.Amount < 0 {
+			if transaction.Balance < 0 {
+				return ErrInsufficientStars
+			}
+			if err := checkBalance(ctx, tx, ownerID); err != nil {
+				return err
+			}
+		}
+		_, err = completeIfTargetReached(ctx, tx, goalID)
+		return err
+	})
+}
+
+// ListStarTransactions 按时间顺序返回目标的星数流水，并计算每笔流水后的余额
+func (s *SQLStore) ListStarTransactions(ctx context.Context, goalID int) ([]models.StarTransaction, error) {
+	query := `SELECT id, goal_id, type, amount, reason, source, source_id, created_at FROM star_transactions WHERE goal_id = ? ORDER BY id ASC`
+	rows, err := s.db.QueryContext(ctx, query, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.StarTransaction
+	for rows.Next() {
+		var t models.StarTransaction
+		if err := rows.Scan(&t.ID, &t.GoalID, &t.Type, &t.Amount, &t.Reason, &t.Source, &t.SourceID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return withRunningBalance(transactions), nil
+}
+
+// ListUserTransactions 按时间顺序返回用户所有目标在 since 到 until（不含）之间的星数流水
+func (s *SQLStore) ListUserTransactions(ctx context.Context, ownerID int, since, until time.Time) ([]models.StarTransaction, error) {
+	query := `SELECT t.id, t.goal_id, t.type, t.amount, t.reason, t.source, t.source_id, t.created_at FROM star_transactions t
+		JOIN star_goals g ON g.id = t.goal_id WHERE g.owner_id = ? AND t.created_at >= ? AND t.created_at < ? ORDER BY t.id ASC`
+	rows, err := s.db.QueryContext(ctx, query, ownerID, s.timeArg(since), s.timeArg(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.StarTransaction{}
+	for rows.Next() {
+		var t models.StarTransaction
+		if err := rows.Scan(&t.ID, &t.GoalID, &t.Type, &t.Amount, &t.Reason, &t.Source, &t.SourceID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// lockUser 锁定用户行，用户不存在时返回 ErrNotFound
+// 会减少用户星数余额的事务都先锁定用户行，使它们对同一用户串行执行
+func (s *SQLStore) lockUser(ctx context.Context, tx *sql.Tx, userID int) error {
+	var id int
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = ?`+s.lockClause(), userID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// lockGoalOwner 以加锁读锁定目标行，再锁定目标所属用户的行，返回用户ID（目标尚无所属用户时为空），目标不存在时返回 ErrNotFound
+// 必须作为事务的第一条语句调用：MySQL 的可重复读事务在第一次普通读时建立快照，
+// 两次加锁读都不建立快照，因此之后的普通读（如已有评分和星数余额）能看到此前持有同一把锁的事务提交的数据
+// 加锁顺序为目标行、用户行；兑换心愿只锁定用户行，不会与之形成环
+func (s *SQLStore) lockGoalOwner(ctx context.Context, tx *sql.Tx, goalID int) (*int, error) {
+	var ownerID *int
+	query := `SELECT owner_id FROM star_goals WHERE id = ?` + s.lockClause()
+	if err := tx.QueryRowContext(ctx, query, goalID).Scan(&ownerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if ownerID == nil {
+		return nil, nil
+	}
+	return ownerID, s.lockUser(ctx, tx, *ownerID)
+}
+
+// checkBalance 在事务中确认用户的星数余额不为负，否则返回 ErrInsufficientStars，用户为空时不检查
+// 调用前事务必须已经通过 lockGoalOwner 或 lockUser 锁定用户行，并且此前没有普通读，使汇总读取的快照包含其他事务已提交的流水
+func checkBalance(ctx context.Context, tx *sql.Tx, ownerID *int) error {
+	if ownerID == nil {
+		return nil
+	}
+	var balance int
+	if err := tx.QueryRowContext(ctx, totalStarsQuery, *ownerID).Scan(&balance); err != nil {
+		return err
+	}
+	if balance < 0 {
+		return ErrInsufficientStars
+	}
+	return nil
+}
+
+// insertStarTransaction 在事务中插入一笔星数流水，并回填ID和创建时间
+func insertStarTransaction(ctx context.Context, tx *sql.Tx, transaction *models.StarTransaction) error {
+	query := `INSERT INTO star_transactions (goal_id, owner_id, type, amount, reason, source, source_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	result, err := tx.ExecContext(ctx, query, transaction.GoalID, transaction.OwnerID, transaction.Type, transaction.Amount, transaction.Reason, transaction.Source, transaction.SourceID)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	transaction.ID = int(id)
+	transaction.CreatedAt = time.Now()
+	return nil
+}
+
+// withRunningBalance 按顺序累加流水金额，填充每笔流水后的余额
+func withRunningBalance(transactions []models.StarTransaction) []models.StarTransaction {
+	balance := 0
+	for i := range transactions {
+		balance += transactions[i].Amount
+		transactions[i].Balance = balance
+	}
+	return transactions
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"starpool/search"
+	"strings"
+	"unicode/utf8"
+)
+
+// SearchDocuments 获取用户的目标和评论中包含任一检索词的候选文档
+// MySQL 使用 ngram 全文索引匹配，SQLite 使用 LIKE 匹配
+func (s *SQLStore) SearchDocuments(ctx context.Context, ownerID int, terms []string, limit int) ([]search.Document, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var documents []search.Document
+	condition, args := s.searchCondition(terms, "g.title", "g.description")
+	query := `SELECT g.id, g.title, COALESCE(g.description, '') FROM star_goals g WHERE g.owner_id = ? AND ` + condition + ` ORDER BY g.id DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, append(append([]interface{}{ownerID}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		document := search.Document{Type: search.DocumentGoal}
+		if err := rows.Scan(&document.ID, &document.Title, &document.Body); err != nil {
+			return nil, err
+		}
+		document.GoalID, document.GoalTitle = document.ID, document.Title
+		documents = append(documents, document)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	condition, args = s.searchCondition(terms, "c.content")
+	query = `SELECT c.id, g.id, g.title, c.content FROM comments c JOIN star_goals g ON g.id = c.goal_id WHERE g.owner_id = ? AND ` + condition + ` ORDER BY c.id DESC LIMIT ?`
+	rows, err = s.db.QueryContext(ctx, query, append(append([]interface{}{ownerID}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		document := search.Document{Type: search.DocumentComment}
+		if err := rows.Scan(&document.ID, &document.GoalID, &document.GoalTitle, &document.Body); err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+// searchCondition 生成匹配任一检索词的查询条件
+func (s *SQLStore) searchCondition(terms []string, columns ...string) (string, []interface{}) {
+	if s.dialect == DialectMySQL {
+		// 布尔模式下无运算符的多个词表示匹配任一词；
+		// 短于 ngram_token_size（默认2）的单字以前缀通配符匹配
+		words := make([]string, len(terms))
+		for i, term := range terms {
+			words[i] = term
+			if utf8.RuneCountInString(term) < 2 {
+				words[i] += "*"
+			}
+		}
+		return `MATCH(` + strings.Join(columns, ", ") + `) AGAINST (? IN BOOLEAN MODE)`, []interface{}{strings.Join(words, " ")}
+	}
+
+	var conditions []string
+	var args []interface{}
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	for _, term := range terms {
+		for _, column := range columns {
+			conditions = append(conditions, column+` LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escaper.Replace(term)+"%")
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
@@ -0,0 +1,745 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"starpool/models"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+// 支持的SQL方言
+const (
+	DialectMySQL  = "mysql"
+	DialectSQLite = "sqlite"
+)
+
+// SQLStore 基于 database/sql 的存储实现，支持 MySQL 和 SQLite
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// goalColumns 目标查询的列，星数由星数流水汇总得出
+// 列的顺序与 goalFields 返回的扫描目标一致
+const goalColumns = `g.id, COALESCE(g.owner_id, 0), g.parent_id, g.title, g.description, g.category,
+	(SELECT COALESCE(SUM(t.amount), 0) FROM star_transactions t WHERE t.goal_id = g.id) AS stars,
+	g.target_stars, g.due_date, g.schedule, g.status, g.completed_at, g.archived_at, g.created_at, g.updated_at`
+
+// NewSQLStore 使用已建立的数据库连接和对应的SQL方言创建 SQLStore
+func NewSQLStore(db *sql.DB, dialect string) *SQLStore {
+	return &SQLStore{db: db, dialect: dialect}
+}
+
+// CreateGoal 创建新目标，类别不存在时自动创建，初始星数不为0时记录一笔调整流水，初始星数已达到目标星数时目标直接完成
+func (s *SQLStore) CreateGoal(ctx context.Context, goal *models.StarGoal) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		goal.Status = models.GoalStatusActive
+		goal.CompletedAt, goal.ArchivedAt = nil, nil
+		query := `INSERT INTO star_goals(owner_id, parent_id, title, description, category, target_stars, due_date, schedule, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+		result, err := tx.ExecContext(ctx, query, goal.OwnerID, goal.ParentID, goal.Title, goal.Description, goal.Category, goal.TargetStars, goal.DueDate, goal.Schedule, goal.Status)
+		if err != nil {
+			return err
+		}
+
+		// 获取插入记录的ID
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		goal.ID = int(id)
+		goal.CreatedAt = now
+		goal.UpdatedAt = now
+		goal.ComputeProgress(models.DateOf(now))
+		if err := ensureCategory(ctx, tx, goal.OwnerID, goal.Category); err != nil {
+			return err
+		}
+		if err := saveGoalTags(ctx, tx, goal); err != nil {
+			return err
+		}
+
+		if goal.Stars == 0 {
+			return nil
+		}
+		err = insertStarTransaction(ctx, tx, &models.StarTransaction{
+			GoalID: &goal.ID,
+			Type:   models.StarTransactionAdjust,
+			Amount: goal.Stars,
+			Reason: "创建目标时的初始星数",
+			Source: models.StarSourceManual,
+		})
+		if err != nil {
+			return err
+		}
+		completed, err := completeIfTargetReached(ctx, tx, goal.ID)
+		if completed {
+			goal.Status = models.GoalStatusCompleted
+			goal.CompletedAt = &now
+		}
+		return err
+	})
+}
+
+// GetGoal 根据ID获取单个目标
+func (s *SQLStore) GetGoal(ctx context.Context, id int) (*models.StarGoal, error) {
+	var goal models.StarGoal
+	query := `SELECT ` + goalColumns + ` FROM star_goals g WHERE g.id = ?`
+	err := s.db.QueryRowContext(ctx, query, id).Scan(goalFields(&goal)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	goal.ComputeProgress(models.DateOf(time.Now()))
+	goals := []models.StarGoal{goal}
+	if err := s.attachTags(ctx, goals); err != nil {
+		return nil, err
+	}
+	return &goals[0], nil
+}
+
+// UpdateGoal 更新目标，星数只能通过流水变动，不在此更新
+// 调低目标星数使其已经达到时，目标自动完成；父目标不能是目标自身或其子孙目标
+// MySQL 对值未变化的行不计入影响行数，因此先锁定并确认目标存在，而不是检查影响行数
+func (s *SQLStore) UpdateGoal(ctx context.Context, goal *models.StarGoal) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		// 类别和标签属于目标的所属用户
+		query := `SELECT COALESCE(owner_id, 0) FROM star_goals WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, goal.ID).Scan(&goal.OwnerID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := s.checkGoalParent(ctx, tx, goal.ID, goal.ParentID); err != nil {
+			return err
+		}
+
+		query = `UPDATE star_goals SET parent_id = ?, title = ?, description = ?, category = ?, target_stars = ?, due_date = ?, schedule = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query, goal.ParentID, goal.Title, goal.Description, goal.Category, goal.TargetStars, goal.DueDate, goal.Schedule, goal.ID)
+		if err != nil {
+			return err
+		}
+		if err := ensureCategory(ctx, tx, goal.OwnerID, goal.Category); err != nil {
+			return err
+		}
+		if err := saveGoalTags(ctx, tx, goal); err != nil {
+			return err
+		}
+		_, err = completeIfTargetReached(ctx, tx, goal.ID)
+		return err
+	})
+}
+
+// UpdateGoalStatus 变更目标状态，仅当目标当前为 from 状态时生效
+// 变更为已完成时记录完成时间（已有则保留），回到进行中或暂停时清除；变更为已归档时记录归档时间，离开归档时清除
+func (s *SQLStore) UpdateGoalStatus(ctx context.Context, id int, from, to string) error {
+	completedAt := "completed_at"
+	switch to {
+	case models.GoalStatusCompleted:
+		completedAt = "COALESCE(completed_at, CURRENT_TIMESTAMP)"
+	case models.GoalStatusActive, models.GoalStatusPaused:
+		completedAt = "NULL"
+	}
+	archivedAt := "NULL"
+	if to == models.GoalStatusArchived {
+		archivedAt = "CURRENT_TIMESTAMP"
+	}
+
+	query := `UPDATE star_goals SET status = ?, completed_at = ` + completedAt + `, archived_at = ` + archivedAt + `, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`
+	result, err := s.db.ExecContext(ctx, query, to, id, from)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != ErrNotFound {
+		return err
+	}
+
+	// 区分目标不存在和状态已被并发修改
+	if _, err := s.GetGoal(ctx, id); err != nil {
+		return err
+	}
+	return ErrConflict
+}
+
+// DeleteGoal 删除目标，评分和评论由外键级联删除，星数流水的 goal_id 由外键置空，流水和星数余额保留
+// cascade 为 true 时一并删除所有子孙目标，否则将子目标转移到被删除目标的父目标下
+func (s *SQLStore) DeleteGoal(ctx context.Context, id int, cascade bool) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var parentID *int
+		query := `SELECT parent_id FROM star_goals WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&parentID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		ids := []int{id}
+		if cascade {
+			descendants, err := descendantIDs(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, descendants...)
+		} else {
+			query = `UPDATE star_goals SET parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE parent_id = ?`
+			if _, err := tx.ExecContext(ctx, query, parentID, id); err != nil {
+				return err
+			}
+		}
+
+		query, args := inClause(`DELETE FROM star_goals WHERE id IN `, ids)
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+// totalStarsQuery 汇总用户星数余额的查询，参数为用户ID；每笔流水都记录所属用户，包括已删除目标留下的流水
+const totalStarsQuery = `SELECT COALESCE(SUM(amount), 0) FROM star_transactions WHERE owner_id = ?`
+
+// TotalStars 获取用户的星数余额，即其目标（包括已删除的目标）所有星数流水与兑换心愿等用户流水的总和
+func (s *SQLStore) TotalStars(ctx context.Context, ownerID int) (int, error) {
+	var totalStars int
+	err := s.db.QueryRowContext(ctx, totalStarsQuery, ownerID).Scan(&totalStars)
+	return totalStars, err
+}
+
+// SaveDailyRating 在一个事务中插入或更新每日评分记录，并记录对应的星数流水
+// 新评分记为获得星数，修改评分时记录新旧评分的差额调整
+// 覆盖当天已有的评分时，merge 指定的备注或心情沿用加锁读到的已有值，并发的部分修改不会互相覆盖
+// 事务开始时锁定目标行和所属用户的行，使同一目标的并发评分以及同一用户的兑换串行执行，调低评分后用户的星数余额为负时回滚
+func (s *SQLStore) SaveDailyRating(ctx context.Context, rating *models.DailyRating, merge RatingMerge) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		ownerID, err := s.lockGoalOwner(ctx, tx, rating.GoalID)
+		if err != nil {
+			return err
+		}
+
+		// 锁定目标行，同时检查目标是否存在且处于进行中
+		var status string
+		query := `SELECT status FROM star_goals WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, rating.GoalID).Scan(&status); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if status != models.GoalStatusActive {
+			return ErrGoalNotActive
+		}
+
+		// 以加锁读查询当天是否已有评分，读到最新提交的评分、备注和心情
+		var previous int
+		var note, mood string
+		query = `SELECT id, rating, COALESCE(note, ''), mood FROM daily_ratings WHERE goal_id = ? AND date = ?` + s.lockClause()
+		err = tx.QueryRowContext(ctx, query, rating.GoalID, rating.Date).Scan(&rating.ID, &previous, &note, &mood)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if merge.KeepNote {
+			rating.Note = note
+		}
+		if merge.KeepMood {
+			rating.Mood = mood
+		}
+
+		transaction := &models.StarTransaction{GoalID: &rating.GoalID, OwnerID: ownerID, Source: models.StarSourceDailyRating}
+		if err == sql.ErrNoRows {
+			// 插入新的每日评分记录
+			query = `INSERT INTO daily_ratings (goal_id, rating, date, note, mood, created_at) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+			result, err := tx.ExecContext(ctx, query, rating.GoalID, rating.Rating, rating.Date, rating.Note, rating.Mood)
+			if err != nil {
+				return err
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			rating.ID = int(id)
+			transaction.Type = models.StarTransactionEarn
+			transaction.Amount = rating.Rating
+			transaction.Reason = "每日评分"
+		} else {
+			// 覆盖当天已有的评分
+			query = `UPDATE daily_ratings SET rating = ?, note = ?, mood = ?, created_at = CURRENT_TIMESTAMP WHERE id = ?`
+			if _, err := tx.ExecContext(ctx, query, rating.Rating, rating.Note, rating.Mood, rating.ID); err != nil {
+				return err
+			}
+			transaction.Type = models.StarTransactionAdjust
+			transaction.Amount = rating.Rating - previous
+			transaction.Reason = fmt.Sprintf("修改评分 %d → %d", previous, rating.Rating)
+		}
+		rating.CreatedAt = time.Now()
+
+		query = `UPDATE star_goals SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, rating.GoalID); err != nil {
+			return err
+		}
+
+		if transaction.Amount == 0 {
+			return nil
+		}
+		transaction.SourceID = &rating.ID
+		if err := insertStarTransaction(ctx, tx, transaction); err != nil {
+			return err
+		}
+		if transaction.Amount < 0 {
+			if err := checkBalance(ctx, tx, ownerID); err != nil {
+				return err
+			}
+		}
+		_, err = completeIfTargetReached(ctx, tx, rating.GoalID)
+		return err
+	})
+}
+
+// ListDailyRatings 获取目标在 from 到 to（含）之间的每日评分记录
+func (s *SQLStore) ListDailyRatings(ctx context.Context, goalID int, from, to models.Date) ([]models.DailyRating, error) {
+	query := `SELECT id, goal_id, rating, date, COALESCE(note, ''), mood, created_at FROM daily_ratings
+		WHERE goal_id = ? AND date >= ? AND date <= ? ORDER BY date`
+	rows, err := s.db.QueryContext(ctx, query, goalID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := []models.DailyRating{}
+	for rows.Next() {
+		var rating models.DailyRating
+		if err := rows.Scan(&rating.ID, &rating.GoalID, &rating.Rating, &rating.Date, &rating.Note, &rating.Mood, &rating.CreatedAt); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rating)
+	}
+	return ratings, rows.Err()
+}
+
+// ListRatingDates 获取各目标在 from 及之后评分不低于 minRating 的日期
+func (s *SQLStore) ListRatingDates(ctx context.Context, goalIDs []int, minRating int, from models.Date) (map[int][]models.Date, error) {
+	dates := make(map[int][]models.Date)
+	if len(goalIDs) == 0 {
+		return dates, nil
+	}
+	query, args := inClause(`SELECT goal_id, date FROM daily_ratings WHERE rating >= ? AND date >= ? AND goal_id IN `, goalIDs)
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY goal_id, date`, append([]interface{}{minRating, from}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var goalID int
+		var date models.Date
+		if err := rows.Scan(&goalID, &date); err != nil {
+			return nil, err
+		}
+		dates[goalID] = append(dates[goalID], date)
+	}
+	return dates, rows.Err()
+}
+
+// ListUserRatings 获取用户所有目标在 from 到 to（含）之间的每日评分
+func (s *SQLStore) ListUserRatings(ctx context.Context, ownerID int, from, to models.Date) ([]models.DailyRating, error) {
+	query := `SELECT r.id, r.goal_id, r.rating, r.date, COALESCE(r.note, ''), r.mood, r.created_at FROM daily_ratings r
+		JOIN star_goals g ON g.id = r.goal_id WHERE g.owner_id = ? AND r.date >= ? AND r.date <= ? ORDER BY r.date, r.goal_id`
+	rows, err := s.db.QueryContext(ctx, query, ownerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := []models.DailyRating{}
+	for rows.Next() {
+		var rating models.DailyRating
+		if err := rows.Scan(&rating.ID, &rating.GoalID, &rating.Rating, &rating.Date, &rating.Note, &rating.Mood, &rating.CreatedAt); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rating)
+	}
+	return ratings, rows.Err()
+}
+
+// SumUserRatings 汇总用户所有目标中评分不低于 minRating 的评分次数和星数
+func (s *SQLStore) SumUserRatings(ctx context.Context, ownerID, minRating int) (models.RatingTotal, error) {
+	var total models.RatingTotal
+	query := `SELECT COUNT(*), COALESCE(SUM(r.rating), 0) FROM daily_ratings r JOIN star_goals g ON g.id = r.goal_id
+		WHERE g.owner_id = ? AND r.rating >= ?`
+	err := s.db.QueryRowContext(ctx, query, ownerID, minRating).Scan(&total.Count, &total.Stars)
+	return total, err
+}
+
+// SumRatingsByDay 按日期汇总用户满足条件的评分次数和星数
+func (s *SQLStore) SumRatingsByDay(ctx context.Context, query HeatmapQuery) ([]models.DayTotal, error) {
+	statement := `SELECT r.date, COUNT(*), SUM(r.rating) FROM daily_ratings r JOIN star_goals g ON g.id = r.goal_id
+		WHERE g.owner_id = ? AND r.date >= ? AND r.date <= ?`
+	args := []interface{}{query.OwnerID, query.From, query.To}
+	if query.GoalID != 0 {
+		statement += ` AND g.id = ?`
+		args = append(args, query.GoalID)
+	}
+	if query.Category != "" {
+		statement += ` AND g.category = ?`
+		args = append(args, query.Category)
+	}
+	rows, err := s.db.QueryContext(ctx, statement+` GROUP BY r.date ORDER BY r.date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []models.DayTotal{}
+	for rows.Next() {
+		var total models.DayTotal
+		if err := rows.Scan(&total.Date, &total.Count, &total.Stars); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// SumRatingsForLeaderboard 按用户、目标或类别汇总评分，排除退出排行榜的用户和未分类的目标
+func (s *SQLStore) SumRatingsForLeaderboard(ctx context.Context, query LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	// 分组列之外的列用常量补齐，常量不能出现在 GROUP BY 中（整数常量会被当作列序号）
+	var columns, groups string
+	switch query.By {
+	case models.LeaderboardGoals:
+		columns, groups = `u.id, u.username, g.id, g.title, ''`, `u.id, u.username, g.id, g.title`
+	case models.LeaderboardCategories:
+		columns, groups = `u.id, u.username, 0, '', g.category`, `u.id, u.username, g.category`
+	default:
+		columns, groups = `u.id, u.username, 0, '', ''`, `u.id, u.username`
+	}
+	statement := `SELECT ` + columns + `, SUM(r.rating), COUNT(*) FROM daily_ratings r
+		JOIN star_goals g ON g.id = r.goal_id JOIN users u ON u.id = g.owner_id
+		WHERE u.leaderboard_opt_out = ?`
+	args := []interface{}{false}
+	if query.By == models.LeaderboardCategories {
+		statement += ` AND g.category <> ''`
+	}
+	if query.From != nil {
+		statement += ` AND r.date >= ?`
+		args = append(args, *query.From)
+	}
+	if query.To != nil {
+		statement += ` AND r.date <= ?`
+		args = append(args, *query.To)
+	}
+	rows, err := s.db.QueryContext(ctx, statement+` GROUP BY `+groups, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.LeaderboardEntry{}
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		if err := rows.Scan(&entry.UserID, &entry.Username, &entry.GoalID, &entry.Title, &entry.Category,
+			&entry.Stars, &entry.Ratings); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ListJournal 获取用户所有目标带备注的每日评分，按日期升序
+func (s *SQLStore) ListJournal(ctx context.Context, ownerID int, from, to *models.Date) ([]models.JournalEntry, error) {
+	query := `SELECT r.id, r.goal_id, g.title, r.date, r.rating, r.mood, r.note FROM daily_ratings r
+		JOIN star_goals g ON g.id = r.goal_id WHERE g.owner_id = ? AND r.note IS NOT NULL AND r.note <> ''`
+	args := []interface{}{ownerID}
+	if from != nil {
+		query += ` AND r.date >= ?`
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += ` AND r.date <= ?`
+		args = append(args, *to)
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY r.date, r.goal_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.JournalEntry{}
+	for rows.Next() {
+		var entry models.JournalEntry
+		if err := rows.Scan(&entry.RatingID, &entry.GoalID, &entry.GoalTitle, &entry.Date, &entry.Rating, &entry.Mood, &entry.Note); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// CreateComment 创建新评论
+func (s *SQLStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	query := `INSERT INTO comments(goal_id, parent_id, content, created_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+	result, err := s.db.ExecContext(ctx, query, comment.GoalID, comment.ParentID, comment.Content)
+	if err != nil {
+		return err
+	}
+
+	// 获取插入记录的ID
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	comment.ID = int(id)
+	comment.CreatedAt = time.Now()
+	comment.EditedAt, comment.DeletedAt = nil, nil
+	return nil
+}
+
+// GetComment 获取属于指定目标的评论
+func (s *SQLStore) GetComment(ctx context.Context, goalID int, id int) (*models.Comment, error) {
+	var comment models.Comment
+	query := `SELECT id, goal_id, parent_id, content, created_at, edited_at, deleted_at FROM comments WHERE id = ? AND goal_id = ?`
+	err := s.db.QueryRowContext(ctx, query, id, goalID).Scan(&comment.ID, &comment.GoalID, &comment.ParentID, &comment.Content, &comment.CreatedAt, &comment.EditedAt, &comment.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListComments 获取目标的所有评论
+func (s *SQLStore) ListComments(ctx context.Context, goalID int) ([]models.Comment, error) {
+	query := `SELECT id, goal_id, parent_id, content, created_at, edited_at, deleted_at FROM comments WHERE goal_id = ? ORDER BY created_at ASC`
+	rows, err := s.db.QueryContext(ctx, query, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.GoalID, &comment.ParentID, &comment.Content, &comment.CreatedAt, &comment.EditedAt, &comment.DeletedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// UpdateComment 在一个事务中保存评论的旧内容到编辑历史并修改内容
+func (s *SQLStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var content string
+		var deletedAt *time.Time
+		query := `SELECT content, deleted_at FROM comments WHERE id = ? AND goal_id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, comment.ID, comment.GoalID).Scan(&content, &deletedAt); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if deletedAt != nil {
+			return ErrCommentDeleted
+		}
+
+		query = `INSERT INTO comment_edits (comment_id, content, edited_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+		if _, err := tx.ExecContext(ctx, query, comment.ID, content); err != nil {
+			return err
+		}
+		query = `UPDATE comments SET content = ?, edited_at = CURRENT_TIMESTAMP WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, comment.Content, comment.ID); err != nil {
+			return err
+		}
+		now := time.Now()
+		comment.EditedAt = &now
+		return nil
+	})
+}
+
+// DeleteComment 在一个事务中删除评论，有回复的评论保留为占位，回复不受外键级联删除影响
+func (s *SQLStore) DeleteComment(ctx context.Context, goalID int, id int) (bool, error) {
+	tombstone := false
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var parentID *int
+		var deletedAt *time.Time
+		query := `SELECT parent_id, deleted_at FROM comments WHERE id = ? AND goal_id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, id, goalID).Scan(&parentID, &deletedAt); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if deletedAt != nil {
+			return ErrCommentDeleted
+		}
+
+		var replies int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE parent_id = ?`, id).Scan(&replies); err != nil {
+			return err
+		}
+		if replies > 0 {
+			tombstone = true
+			if _, err := tx.ExecContext(ctx, `UPDATE comments SET content = '', deleted_at = CURRENT_TIMESTAMP WHERE id = ?`, id); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM comment_edits WHERE comment_id = ?`, id)
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, id); err != nil {
+			return err
+		}
+
+		// 依次删除不再有回复的已删除祖先评论
+		for parentID != nil {
+			id := *parentID
+			query := `SELECT parent_id, deleted_at, (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id) FROM comments c WHERE c.id = ?`
+			if err := tx.QueryRowContext(ctx, query, id).Scan(&parentID, &deletedAt, &replies); err != nil {
+				return err
+			}
+			if deletedAt == nil || replies > 0 {
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return tombstone, err
+}
+
+// ListCommentEdits 获取评论的编辑历史
+func (s *SQLStore) ListCommentEdits(ctx context.Context, commentID int) ([]models.CommentEdit, error) {
+	query := `SELECT id, comment_id, content, edited_at FROM comment_edits WHERE comment_id = ? ORDER BY id`
+	rows, err := s.db.QueryContext(ctx, query, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := []models.CommentEdit{}
+	for rows.Next() {
+		var edit models.CommentEdit
+		if err := rows.Scan(&edit.ID, &edit.CommentID, &edit.Content, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	return edits, rows.Err()
+}
+
+// queryGoals 执行目标查询并扫描结果
+func (s *SQLStore) queryGoals(ctx context.Context, query string, args ...interface{}) ([]models.StarGoal, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	today := models.DateOf(time.Now())
+	var goals []models.StarGoal
+	for rows.Next() {
+		var goal models.StarGoal
+		if err := rows.Scan(goalFields(&goal)...); err != nil {
+			return nil, err
+		}
+		goal.ComputeProgress(today)
+		goals = append(goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return goals, s.attachTags(ctx, goals)
+}
+
+// completeIfTargetReached 目标星数已经达到时，将进行中或已暂停的目标标记为已完成，返回目标是否因此完成
+func completeIfTargetReached(ctx context.Context, tx *sql.Tx, goalID int) (bool, error) {
+	query := `UPDATE star_goals SET status = ?, completed_at = COALESCE(completed_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN (?, ?) AND target_stars IS NOT NULL
+		AND (SELECT COALESCE(SUM(t.amount), 0) FROM star_transactions t WHERE t.goal_id = star_goals.id) >= target_stars`
+	result, err := tx.ExecContext(ctx, query, models.GoalStatusCompleted, goalID, models.GoalStatusActive, models.GoalStatusPaused)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// goalFields 返回与 goalColumns 顺序一致的扫描目标
+func goalFields(goal *models.StarGoal) []interface{} {
+	return []interface{}{
+		&goal.ID, &goal.OwnerID, &goal.ParentID, &goal.Title, &goal.Description, &goal.Category, &goal.Stars,
+		&goal.TargetStars, &goal.DueDate, &goal.Schedule, &goal.Status, &goal.CompletedAt, &goal.ArchivedAt, &goal.CreatedAt, &goal.UpdatedAt,
+	}
+}
+
+// withTx 在事务中执行 fn，fn 返回错误时回滚
+// 死锁、锁等待超时等并发冲突统一转换为 ErrConflict
+func (s *SQLStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return conflictError(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return conflictError(err)
+	}
+	return conflictError(tx.Commit())
+}
+
+// lockClause 返回当前方言下锁定所读行的子句
+// SQLite 没有行锁，写事务以 BEGIN IMMEDIATE 开始即获得整个数据库的写锁
+func (s *SQLStore) lockClause() string {
+	if s.dialect == DialectSQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// conflictError 将驱动返回的死锁和锁超时错误转换为 ErrConflict
+func conflictError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && (mysqlErr.Number == 1213 || mysqlErr.Number == 1205) {
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return err
+}
+
+// duplicateError 将驱动返回的唯一约束冲突错误转换为 ErrDuplicate
+func duplicateError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return fmt.Errorf("%w: %v", ErrDuplicate, err)
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey) {
+		return fmt.Errorf("%w: %v", ErrDuplicate, err)
+	}
+	return err
+}
+
+// checkAffected 在没有记录受影响时返回 ErrNotFound
+func checkAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
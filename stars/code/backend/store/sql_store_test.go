@@ -0,0 +1,405 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"starpool/migrations"
+	"starpool/models"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestSQLiteStore 在临时目录中创建已应用所有迁移的 SQLite 存储，连接参数与 config.openSQLite 一致
+func newTestSQLiteStore(t *testing.T) *SQLStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "starpool.db")
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, DialectSQLite, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewSQLStore(db, DialectSQLite)
+}
+
+// newTestMySQLStore 连接 STARPOOL_TEST_MYSQL_DSN 指定的空数据库并应用所有迁移，未设置时跳过测试
+// DSN 需要与 config.openMySQL 一致地带上 parseTime=true&clientFoundRows=true&loc=UTC
+func newTestMySQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	dsn := os.Getenv("STARPOOL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("未设置 STARPOOL_TEST_MYSQL_DSN")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, DialectMySQL, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewSQLStore(db, DialectMySQL)
+}
+
+// testStores 并发测试覆盖的存储实现，MySQL 方言的加锁读只有在设置了测试数据库时才会运行
+var testStores = map[string]func(t *testing.T) Store{
+	"sqlite": func(t *testing.T) Store { return newTestSQLiteStore(t) },
+	"mysql":  func(t *testing.T) Store { return newTestMySQLStore(t) },
+	"memory": func(t *testing.T) Store { return NewMemoryStore() },
+}
+
+// createTestGoal 创建用户名唯一的用户及其目标，MySQL 测试数据库可以重复使用
+func createTestGoal(t *testing.T, s Store) (models.User, models.StarGoal) {
+	t.Helper()
+	ctx := context.Background()
+	user := models.User{Username: fmt.Sprintf("user%d", time.Now().UnixNano()), PasswordHash: "x"}
+	if err := s.CreateUser(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	goal := models.StarGoal{OwnerID: user.ID, Title: "跑步"}
+	if err := s.CreateGoal(ctx, &goal); err != nil {
+		t.Fatal(err)
+	}
+	return user, goal
+}
+
+// TestSaveDailyRatingConcurrent 并发地为同一目标的若干天评分和改分，星数流水的合计应始终等于评分的合计
+func TestSaveDailyRatingConcurrent(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			_, goal := createTestGoal(t, s)
+
+			// 40 个并发请求落在 5 个日期上，同一天的请求互相覆盖，既有新增也有改分
+			const requests, days = 40, 5
+			today := models.DateOf(time.Now().UTC())
+			var wg sync.WaitGroup
+			errs := make(chan error, requests)
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					rating := models.DailyRating{
+						GoalID: goal.ID,
+						Rating: i%5 + 1,
+						Date:   models.Date{Time: today.AddDate(0, 0, -(i % days))},
+					}
+					// 并发冲突时整个事务回滚，不影响星数与评分一致
+					if err := s.SaveDailyRating(ctx, &rating, RatingMerge{}); err != nil && !errors.Is(err, ErrConflict) {
+						errs <- err
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatal(err)
+			}
+
+			ratings, err := s.ListDailyRatings(ctx, goal.ID, models.Date{Time: today.AddDate(0, 0, -days)}, today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ratings) != days {
+				t.Fatalf("评分天数 = %d，期望 %d", len(ratings), days)
+			}
+			ratingSum := 0
+			for _, rating := range ratings {
+				ratingSum += rating.Rating
+			}
+
+			transactions, err := s.ListStarTransactions(ctx, goal.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			transactionSum := 0
+			for _, transaction := range transactions {
+				transactionSum += transaction.Amount
+			}
+			if transactionSum != ratingSum {
+				t.Fatalf("星数流水合计 = %d，评分合计 = %d", transactionSum, ratingSum)
+			}
+
+			updated, err := s.GetGoal(ctx, goal.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Stars != ratingSum {
+				t.Fatalf("目标星数 = %d，评分合计 = %d", updated.Stars, ratingSum)
+			}
+		})
+	}
+}
+
+// TestSaveDailyRatingConcurrentSQL 直接在数据库中核对 SQL 存储并发评分后的 SUM(star_transactions) 与 SUM(daily_ratings)
+func TestSaveDailyRatingConcurrentSQL(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) *SQLStore{"sqlite": newTestSQLiteStore, "mysql": newTestMySQLStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			_, goal := createTestGoal(t, s)
+
+			today := models.DateOf(time.Now().UTC())
+			var wg sync.WaitGroup
+			for i := 0; i < 40; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					rating := models.DailyRating{GoalID: goal.ID, Rating: 5 - i%5, Date: models.Date{Time: today.AddDate(0, 0, -(i % 3))}}
+					if err := s.SaveDailyRating(ctx, &rating, RatingMerge{}); err != nil && !errors.Is(err, ErrConflict) {
+						t.Error(err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			var transactionSum, ratingSum int
+			query := `SELECT COALESCE(SUM(amount), 0) FROM star_transactions WHERE goal_id = ?`
+			if err := s.db.QueryRowContext(ctx, query, goal.ID).Scan(&transactionSum); err != nil {
+				t.Fatal(err)
+			}
+			query = `SELECT COALESCE(SUM(rating), 0) FROM daily_ratings WHERE goal_id = ?`
+			if err := s.db.QueryRowContext(ctx, query, goal.ID).Scan(&ratingSum); err != nil {
+				t.Fatal(err)
+			}
+			if ratingSum == 0 || transactionSum != ratingSum {
+				t.Fatalf("SUM(star_transactions) = %d，SUM(daily_ratings) = %d", transactionSum, ratingSum)
+			}
+		})
+	}
+}
+
+// TestAddStarTransactionConcurrent 并发扣减星数时余额检查读到最新的流水，用户的星数余额不会为负
+func TestAddStarTransactionConcurrent(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			user, goal := createTestGoal(t, s)
+
+			rating := models.DailyRating{GoalID: goal.ID, Rating: 5, Date: models.DateOf(time.Now().UTC())}
+			if err := s.SaveDailyRating(ctx, &rating, RatingMerge{}); err != nil {
+				t.Fatal(err)
+			}
+
+			// 20 个并发请求各扣减 1 星，只有 5 个能成功
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					transaction := models.StarTransaction{
+						GoalID: &goal.ID,
+						Type:   models.StarTransactionAdjust,
+						Amount: -1,
+						Reason: "扣减",
+						Source: models.StarSourceManual,
+					}
+					err := s.AddStarTransaction(ctx, &transaction)
+					if err != nil && !errors.Is(err, ErrInsufficientStars) && !errors.Is(err, ErrConflict) {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			total, err := s.TotalStars(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total < 0 {
+				t.Fatalf("星数余额 = %d，不应为负", total)
+			}
+		})
+	}
+}
+
+// TestSaveDailyRatingMergeConcurrent 同一天并发地只修改备注和只修改心情，两次修改都保留
+func TestSaveDailyRatingMergeConcurrent(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			_, goal := createTestGoal(t, s)
+
+			const days = 10
+			today := models.DateOf(time.Now().UTC())
+			var wg sync.WaitGroup
+			for i := 0; i < days; i++ {
+				date := models.Date{Time: today.AddDate(0, 0, -i)}
+				rating := models.DailyRating{GoalID: goal.ID, Rating: 3, Date: date}
+				if err := s.SaveDailyRating(ctx, &rating, RatingMerge{}); err != nil {
+					t.Fatal(err)
+				}
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					rating := models.DailyRating{GoalID: goal.ID, Rating: 4, Date: date, Note: "跑了5公里"}
+					if err := s.SaveDailyRating(ctx, &rating, RatingMerge{KeepMood: true}); err != nil {
+						t.Error(err)
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					rating := models.DailyRating{GoalID: goal.ID, Rating: 4, Date: date, Mood: "good"}
+					if err := s.SaveDailyRating(ctx, &rating, RatingMerge{KeepNote: true}); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			ratings, err := s.ListDailyRatings(ctx, goal.ID, models.Date{Time: today.AddDate(0, 0, -days)}, today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ratings) != days {
+				t.Fatalf("评分天数 = %d，期望 %d", len(ratings), days)
+			}
+			for _, rating := range ratings {
+				if rating.Note != "跑了5公里" || rating.Mood != "good" {
+					t.Fatalf("%s 的备注 = %q，心情 = %q，并发修改丢失", rating.Date, rating.Note, rating.Mood)
+				}
+			}
+		})
+	}
+}
+
+// TestDeleteGoalKeepsLedger 删除星数已被兑换消费的目标时流水保留，星数余额不变
+func TestDeleteGoalKeepsLedger(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			user, goal := createTestGoal(t, s)
+
+			rating := models.DailyRating{GoalID: goal.ID, Rating: 5, Date: models.DateOf(time.Now().UTC())}
+			if err := s.SaveDailyRating(ctx, &rating, RatingMerge{}); err != nil {
+				t.Fatal(err)
+			}
+			wish := models.Wish{OwnerID: user.ID, Title: "看电影", Price: 3}
+			if err := s.CreateWish(ctx, &wish); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.RedeemWish(ctx, wish.ID, user.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.DeleteGoal(ctx, goal.ID, true); err != nil {
+				t.Fatal(err)
+			}
+			total, err := s.TotalStars(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 2 {
+				t.Fatalf("删除目标后星数余额 = %d，期望 2", total)
+			}
+		})
+	}
+}
+
+// TestListRatingDatesFrom 只返回 from 及之后的评分日期，from 为零值时返回所有日期
+func TestListRatingDatesFrom(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			_, goal := createTestGoal(t, s)
+
+			today := models.DateOf(time.Now().UTC())
+			for _, offset := range []int{-10, -2, 0} {
+				rating := models.DailyRating{GoalID: goal.ID, Rating: 4, Date: models.Date{Time: today.AddDate(0, 0, offset)}}
+				if err := s.SaveDailyRating(ctx, &rating, RatingMerge{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dates, err := s.ListRatingDates(ctx, []int{goal.ID}, 1, models.Date{Time: today.AddDate(0, 0, -2)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dates[goal.ID]; len(got) != 2 || !got[0].Equal(today.AddDate(0, 0, -2)) {
+				t.Fatalf("from 之后的评分日期 = %v，期望两天前和今天", got)
+			}
+			if dates, err = s.ListRatingDates(ctx, []int{goal.ID}, 1, models.Date{}); err != nil {
+				t.Fatal(err)
+			}
+			if len(dates[goal.ID]) != 3 {
+				t.Fatalf("所有评分日期 = %v，期望3天", dates[goal.ID])
+			}
+		})
+	}
+}
+
+// TestLedgerMigrationBackfillsOwner 迁移 0018 为已有的目标流水补录所属用户，之后删除目标不再删除流水
+func TestLedgerMigrationBackfillsOwner(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSQLiteStore(t)
+	migrator, err := migrations.New(s.db, DialectSQLite, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 0017 的目标流水不记录所属用户
+	user, goal := createTestGoal(t, s)
+	query := `INSERT INTO star_transactions (goal_id, type, amount, reason, source) VALUES (?, 'earn', 4, '每日评分', 'daily_rating')`
+	if _, err := s.db.ExecContext(ctx, query, goal.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteGoal(ctx, goal.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	var goalID *int
+	var ownerID int
+	query = `SELECT goal_id, owner_id FROM star_transactions WHERE amount = 4`
+	if err := s.db.QueryRowContext(ctx, query).Scan(&goalID, &ownerID); err != nil {
+		t.Fatal(err)
+	}
+	if goalID != nil || ownerID != user.ID {
+		t.Fatalf("删除目标后流水的 goal_id = %v，owner_id = %d，期望为空和 %d", goalID, ownerID, user.ID)
+	}
+	if total, err := s.TotalStars(ctx, user.ID); err != nil || total != 4 {
+		t.Fatalf("星数余额 = %d（%v），期望 4", total, err)
+	}
+
+	// 回滚时删除已删除目标留下的流水
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM star_transactions`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("回滚后流水数 = %d，期望 0", count)
+	}
+}
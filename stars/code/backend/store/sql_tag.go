@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"starpool/models"
+)
+
+// ListTags 获取用户的所有标签及使用各标签的目标数
+func (s *SQLStore) ListTags(ctx context.Context, ownerID int) ([]models.Tag, error) {
+	query := `SELECT t.id, COALESCE(t.owner_id, 0), t.name, t.created_at,
+		(SELECT COUNT(*) FROM goal_tags gt WHERE gt.tag_id = t.id)
+		FROM tags t WHERE t.owner_id = ? ORDER BY t.name`
+	rows, err := s.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.OwnerID, &tag.Name, &tag.CreatedAt, &tag.GoalCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetTag 根据ID获取标签
+func (s *SQLStore) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	var tag models.Tag
+	query := `SELECT t.id, COALESCE(t.owner_id, 0), t.name, t.created_at,
+		(SELECT COUNT(*) FROM goal_tags gt WHERE gt.tag_id = t.id)
+		FROM tags t WHERE t.id = ?`
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&tag.ID, &tag.OwnerID, &tag.Name, &tag.CreatedAt, &tag.GoalCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// RenameTag 重命名标签，同一用户已有同名标签时返回 ErrDuplicate
+func (s *SQLStore) RenameTag(ctx context.Context, id int, name string) error {
+	query := `UPDATE tags SET name = ? WHERE id = ?`
+	result, err := s.db.ExecContext(ctx, query, name, id)
+	if err != nil {
+		return duplicateError(err)
+	}
+	return checkAffected(result)
+}
+
+// MergeTags 将源标签合并到目标标签，已同时使用两个标签的目标只保留一条关联
+func (s *SQLStore) MergeTags(ctx context.Context, sourceIDs []int, targetID int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		for _, sourceID := range sourceIDs {
+			if sourceID == targetID {
+				continue
+			}
+			query := `INSERT INTO goal_tags (goal_id, tag_id)
+				SELECT goal_id, ? FROM goal_tags
+				WHERE tag_id = ? AND goal_id NOT IN (SELECT goal_id FROM goal_tags WHERE tag_id = ?)`
+			if _, err := tx.ExecContext(ctx, query, targetID, sourceID, targetID); err != nil {
+				return err
+			}
+			// 删除源标签，其关联由外键级联删除
+			result, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, sourceID)
+			if err != nil {
+				return err
+			}
+			if err := checkAffected(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTag 删除标签，其与目标的关联由外键级联删除
+func (s *SQLStore) DeleteTag(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// saveGoalTags 在事务中将目标的标签替换为 goal.Tags，不存在的标签自动创建
+// goal.Tags 会被替换为规范化、去重并排序后的标签名
+func saveGoalTags(ctx context.Context, tx *sql.Tx, goal *models.StarGoal) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM goal_tags WHERE goal_id = ?`, goal.ID); err != nil {
+		return err
+	}
+
+	goal.Tags = normalizeTags(goal.Tags)
+	for _, name := range goal.Tags {
+		var tagID int64
+		query := `SELECT id FROM tags WHERE owner_id = ? AND name = ?`
+		err := tx.QueryRowContext(ctx, query, goal.OwnerID, name).Scan(&tagID)
+		if err == sql.ErrNoRows {
+			query = `INSERT INTO tags (owner_id, name, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+			result, insertErr := tx.ExecContext(ctx, query, goal.OwnerID, name)
+			if insertErr != nil {
+				return duplicateError(insertErr)
+			}
+			tagID, err = result.LastInsertId()
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `INSERT INTO goal_tags (goal_id, tag_id) VALUES (?, ?)`
+		if _, err := tx.ExecContext(ctx, query, goal.ID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachTags 查询并填充目标的标签名
+func (s *SQLStore) attachTags(ctx context.Context, goals []models.StarGoal) error {
+	if len(goals) == 0 {
+		return nil
+	}
+	ids := make([]int, len(goals))
+	byID := make(map[int]*models.StarGoal, len(goals))
+	for i := range goals {
+		goals[i].Tags = []string{}
+		ids[i] = goals[i].ID
+		byID[goals[i].ID] = &goals[i]
+	}
+
+	query, args := inClause(`SELECT gt.goal_id, t.name FROM goal_tags gt JOIN tags t ON t.id = gt.tag_id WHERE gt.goal_id IN `, ids)
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY t.name`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var goalID int
+		var name string
+		if err := rows.Scan(&goalID, &name); err != nil {
+			return err
+		}
+		byID[goalID].Tags = append(byID[goalID].Tags, name)
+	}
+	return rows.Err()
+}
+
+// normalizeTags 规范化标签名，去掉空标签和重复标签并排序
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, name := range names {
+		name = models.NormalizeTagName(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
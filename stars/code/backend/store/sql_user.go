@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"starpool/models"
+	"time"
+)
+
+// CreateUser 创建新用户，第一个用户同时认领所有尚无所属用户的目标、星数流水、标签和类别
+func (s *SQLStore) CreateUser(ctx context.Context, user *models.User) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		query := `INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+		result, err := tx.ExecContext(ctx, query, user.Username, user.PasswordHash)
+		if err != nil {
+			return duplicateError(err)
+		}
+
+		// 获取插入记录的ID
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		user.ID = int(id)
+		user.CreatedAt = time.Now()
+
+		var userCount int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&userCount); err != nil {
+			return err
+		}
+		if userCount > 1 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE star_goals SET owner_id = ? WHERE owner_id IS NULL`, user.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE star_transactions SET owner_id = ? WHERE owner_id IS NULL`, user.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE tags SET owner_id = ? WHERE owner_id IS NULL`, user.ID); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET owner_id = ? WHERE owner_id IS NULL`, user.ID)
+		return err
+	})
+}
+
+// GetUser 根据ID获取用户
+func (s *SQLStore) GetUser(ctx context.Context, id int) (*models.User, error) {
+	query := `SELECT id, username, password_hash, timezone, leaderboard_opt_out, created_at FROM users WHERE id = ?`
+	return s.queryUser(ctx, query, id)
+}
+
+// GetUserByUsername 根据用户名获取用户
+func (s *SQLStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	query := `SELECT id, username, password_hash, timezone, leaderboard_opt_out, created_at FROM users WHERE username = ?`
+	return s.queryUser(ctx, query, username)
+}
+
+// UpdateUserSettings 保存用户的时区和排行榜设置
+// MySQL 对值未变化的行不计入影响行数，因此先确认用户存在，而不是检查影响行数
+func (s *SQLStore) UpdateUserSettings(ctx context.Context, user *models.User) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT 1 FROM users WHERE id = ?`, user.ID).Scan(&exists); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		query := `UPDATE users SET timezone = ?, leaderboard_opt_out = ? WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query, user.Timezone, user.LeaderboardOptOut, user.ID)
+		return err
+	})
+}
+
+// queryUser 执行单个用户查询并扫描结果
+func (s *SQLStore) queryUser(ctx context.Context, query string, args ...interface{}) (*models.User, error) {
+	var user models.User
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Timezone, &user.LeaderboardOptOut, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"starpool/models"
+	"time"
+)
+
+// wishColumns 查询心愿时选取的列，顺序与 wishFields 一致
+const wishColumns = `w.id, w.owner_id, w.title, COALESCE(w.description, ''), w.price, w.approver_id, COALESCE(a.username, ''),
+	w.created_at, w.updated_at FROM wishes w LEFT JOIN users a ON a.id = w.approver_id`
+
+// wishFields 返回与 wishColumns 顺序一致的扫描目标
+func wishFields(wish *models.Wish) []interface{} {
+	return []interface{}{
+		&wish.ID, &wish.OwnerID, &wish.Title, &wish.Description, &wish.Price, &wish.ApproverID, &wish.Approver,
+		&wish.CreatedAt, &wish.UpdatedAt,
+	}
+}
+
+// redemptionColumns 查询兑换记录时选取的列，顺序与 redemptionFields 一致
+const redemptionColumns = `r.id, r.wish_id, r.user_id, u.username, r.title, r.price, r.status, r.approver_id, COALESCE(a.username, ''),
+	r.created_at, r.decided_at FROM wish_redemptions r JOIN users u ON u.id = r.user_id LEFT JOIN users a ON a.id = r.approver_id`
+
+// redemptionFields 返回与 redemptionColumns 顺序一致的扫描目标
+func redemptionFields(redemption *models.Redemption) []interface{} {
+	return []interface{}{
+		&redemption.ID, &redemption.WishID, &redemption.UserID, &redemption.Username, &redemption.Title, &redemption.Price,
+		&redemption.Status, &redemption.ApproverID, &redemption.Approver, &redemption.CreatedAt, &redemption.DecidedAt,
+	}
+}
+
+// ListWishes 获取用户的所有心愿
+func (s *SQLStore) ListWishes(ctx context.Context, ownerID int) ([]models.Wish, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+wishColumns+` WHERE w.owner_id = ? ORDER BY w.id`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wishes := []models.Wish{}
+	for rows.Next() {
+		var wish models.Wish
+		if err := rows.Scan(wishFields(&wish)...); err != nil {
+			return nil, err
+		}
+		wishes = append(wishes, wish)
+	}
+	return wishes, rows.Err()
+}
+
+// GetWish 根据ID获取心愿
+func (s *SQLStore) GetWish(ctx context.Context, id int) (*models.Wish, error) {
+	var wish models.Wish
+	if err := s.db.QueryRowContext(ctx, `SELECT `+wishColumns+` WHERE w.id = ?`, id).Scan(wishFields(&wish)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &wish, nil
+}
+
+// CreateWish 创建新心愿
+func (s *SQLStore) CreateWish(ctx context.Context, wish *models.Wish) error {
+	query := `INSERT INTO wishes (owner_id, title, description, price, approver_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	result, err := s.db.ExecContext(ctx, query, wish.OwnerID, wish.Title, wish.Description, wish.Price, wish.ApproverID)
+	if err != nil {
+		return err
+	}
+
+	// 获取插入记录的ID
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	wish.ID = int(id)
+	wish.CreatedAt = now
+	wish.UpdatedAt = now
+	return nil
+}
+
+// UpdateWish 更新心愿
+// MySQL 对值未变化的行不计入影响行数，因此先确认心愿存在，而不是检查影响行数
+func (s *SQLStore) UpdateWish(ctx context.Context, wish *models.Wish) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var id int
+		if err := tx.QueryRowContext(ctx, `SELECT id FROM wishes WHERE id = ?`+s.lockClause(), wish.ID).Scan(&id); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		query := `UPDATE wishes SET title = ?, description = ?, price = ?, approver_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query, wish.Title, wish.Description, wish.Price, wish.ApproverID, wish.ID)
+		return err
+	})
+}
+
+// DeleteWish 删除心愿，兑换记录的心愿ID由外键置空
+func (s *SQLStore) DeleteWish(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM wishes WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// RedeemWish 在一个事务中检查余额、记录兑换并追加消费流水
+// 事务开始时锁定用户行，使同一用户的并发兑换串行执行
+func (s *SQLStore) RedeemWish(ctx context.Context, wishID, userID int) (*models.Redemption, error) {
+	var redemptionID int
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := s.lockUser(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		var title string
+		var price int
+		var approverID *int
+		query := `SELECT title, price, approver_id FROM wishes WHERE id = ?`
+		if err := tx.QueryRowContext(ctx, query, wishID).Scan(&title, &price, &approverID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		// 检查余额
+		var balance int
+		if err := tx.QueryRowContext(ctx, totalStarsQuery, userID).Scan(&balance); err != nil {
+			return err
+		}
+		if balance < price {
+			return ErrInsufficientStars
+		}
+
+		// 无需审批的兑换直接兑现
+		status, decidedAt := models.RedemptionGranted, "CURRENT_TIMESTAMP"
+		if approverID != nil {
+			status, decidedAt = models.RedemptionPending, "NULL"
+		}
+		query = `INSERT INTO wish_redemptions (wish_id, user_id, title, price, status, approver_id, created_at, decided_at)
+			VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ` + decidedAt + `)`
+		result, err := tx.ExecContext(ctx, query, wishID, userID, title, price, status, approverID)
+		if err != nil {
+			return err
+		}
+		insertedID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		redemptionID = int(insertedID)
+
+		return insertStarTransaction(ctx, tx, &models.StarTransaction{
+			OwnerID:  &userID,
+			Type:     models.StarTransactionSpend,
+			Amount:   -price,
+			Reason:   "兑换心愿：" + title,
+			Source:   models.StarSourceRedemption,
+			SourceID: &redemptionID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRedemption(ctx, redemptionID)
+}
+
+// ListRedemptions 获取满足条件的兑换记录，按兑换时间倒序
+func (s *SQLStore) ListRedemptions(ctx context.Context, query RedemptionQuery) ([]models.Redemption, error) {
+	statement := `SELECT ` + redemptionColumns + ` WHERE 1 = 1`
+	args := []interface{}{}
+	if query.UserID != 0 {
+		statement += ` AND r.user_id = ?`
+		args = append(args, query.UserID)
+	}
+	if query.ApproverID != 0 {
+		statement += ` AND r.approver_id = ?`
+		args = append(args, query.ApproverID)
+	}
+	if query.Status != "" {
+		statement += ` AND r.status = ?`
+		args = append(args, query.Status)
+	}
+	rows, err := s.db.QueryContext(ctx, statement+` ORDER BY r.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []models.Redemption{}
+	for rows.Next() {
+		var redemption models.Redemption
+		if err := rows.Scan(redemptionFields(&redemption)...); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, rows.Err()
+}
+
+// GetRedemption 根据ID获取兑换记录
+func (s *SQLStore) GetRedemption(ctx context.Context, id int) (*models.Redemption, error) {
+	var redemption models.Redemption
+	err := s.db.QueryRowContext(ctx, `SELECT `+redemptionColumns+` WHERE r.id = ?`, id).Scan(redemptionFields(&redemption)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// DecideRedemption 在一个事务中同意或拒绝等待审批的兑换，拒绝时追加退还星数的调整流水
+func (s *SQLStore) DecideRedemption(ctx context.Context, id int, approve bool) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var userID, price int
+		var title, status string
+		query := `SELECT user_id, title, price, status FROM wish_redemptions WHERE id = ?` + s.lockClause()
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&userID, &title, &price, &status); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if status != models.RedemptionPending {
+			return ErrRedemptionDecided
+		}
+
+		status = models.RedemptionGranted
+		if !approve {
+			status = models.RedemptionRejected
+		}
+		query = `UPDATE wish_redemptions SET status = ?, decided_at = CURRENT_TIMESTAMP WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, status, id); err != nil {
+			return err
+		}
+		if approve {
+			return nil
+		}
+		return insertStarTransaction(ctx, tx, &models.StarTransaction{
+			OwnerID:  &userID,
+			Type:     models.StarTransactionAdjust,
+			Amount:   price,
+			Reason:   "心愿兑换被拒绝，退还星数：" + title,
+			Source:   models.StarSourceRedemption,
+			SourceID: &id,
+		})
+	})
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"starpool/models"
+	"starpool/search"
+	"time"
+)
+
+// ErrNotFound 表示请求的记录不存在
+var ErrNotFound = errors.New("记录未找到")
+
+// ErrDuplicate 表示写入违反了唯一约束，例如用户名已被注册
+var ErrDuplicate = errors.New("记录已存在")
+
+// ErrConflict 表示写入因死锁或锁等待超时等并发冲突而失败，客户端可以重试
+var ErrConflict = errors.New("并发写入冲突，请稍后重试")
+
+// ErrGoalNotActive 表示目标不在进行中，不能评分
+var ErrGoalNotActive = errors.New("目标不在进行中，不能评分")
+
+// ErrGoalCycle 表示设置的父目标是目标自身或其子孙目标
+var ErrGoalCycle = errors.New("不能将目标设为自身或其子目标的子目标")
+
+// ErrInsufficientStars 表示操作会使星数余额为负，例如余额不足以兑换心愿
+var ErrInsufficientStars = errors.New("星数余额不足")
+
+// ErrCommentDeleted 表示评论已经删除，只保留为占位，不能再编辑或删除
+var ErrCommentDeleted = errors.New("评论已删除")
+
+// ErrRedemptionDecided 表示兑换申请已经被同意或拒绝，不能再次处理
+var ErrRedemptionDecided = errors.New("兑换申请已经处理")
+
+// GoalStore 定义星目标的存储操作
+type GoalStore interface {
+	// CreateGoal 保存新目标及其标签（不存在的类别和标签自动创建），并回填ID和时间戳
+	CreateGoal(ctx context.Context, goal *models.StarGoal) error
+	// ListGoals 按过滤、排序和分页条件返回用户的一页目标，游标无效时返回 ErrInvalidCursor
+	ListGoals(ctx context.Context, query GoalQuery) (*GoalPage, error)
+	// GetGoal 根据ID返回目标，不存在时返回 ErrNotFound
+	GetGoal(ctx context.Context, id int) (*models.StarGoal, error)
+	// UpdateGoal 更新目标的标题、描述、类别、标签、目标星数、截止日期和父目标，不存在时返回 ErrNotFound，
+	// 父目标是目标自身或其子孙目标时返回 ErrGoalCycle
+	// 星数由星数流水汇总得出，不能直接修改
+	UpdateGoal(ctx context.Context, goal *models.StarGoal) error
+	// UpdateGoalStatus 将目标从 from 状态变更为 to 状态，并维护完成和归档时间，
+	// 不存在时返回 ErrNotFound，目标当前已不是 from 状态时返回 ErrConflict
+	UpdateGoalStatus(ctx context.Context, id int, from, to string) error
+	// DeleteGoal 删除目标及其评分和评论，不存在时返回 ErrNotFound；
+	// 星数流水只追加，目标删除后保留并不再关联目标，所属用户的星数余额不变
+	// cascade 为 true 时一并删除所有子孙目标，否则子目标转移到被删除目标的父目标下
+	DeleteGoal(ctx context.Context, id int, cascade bool) error
+	// ListGoalDescendants 返回目标的所有子孙目标
+	ListGoalDescendants(ctx context.Context, id int) ([]models.StarGoal, error)
+	// TotalStars 返回用户的星数余额，即其目标所有星数流水与兑换心愿等用户流水的总和
+	TotalStars(ctx context.Context, ownerID int) (int, error)
+}
+
+// RatingStore 定义每日评分的存储操作
+type RatingStore interface {
+	// SaveDailyRating 原子地插入或覆盖目标某天的评分（连同备注和心情）并记录对应的星数流水，
+	// merge 指定的字段在同一事务中沿用当天已有评分的值，保存后 rating 为实际保存的评分；
+	// 目标不存在时返回 ErrNotFound，目标不在进行中时返回 ErrGoalNotActive，并发冲突时返回 ErrConflict，
+	// 调低评分使所属用户的星数余额为负时返回 ErrInsufficientStars
+	SaveDailyRating(ctx context.Context, rating *models.DailyRating, merge RatingMerge) error
+	// ListDailyRatings 按日期升序返回目标在 from 到 to（含）之间的评分
+	ListDailyRatings(ctx context.Context, goalID int, from, to models.Date) ([]models.DailyRating, error)
+	// ListRatingDates 返回各目标在 from 及之后评分不低于 minRating 的日期，按日期升序，没有这类评分的目标不在结果中；
+	// from 为零值时返回所有日期
+	ListRatingDates(ctx context.Context, goalIDs []int, minRating int, from models.Date) (map[int][]models.Date, error)
+	// ListUserRatings 按日期升序返回用户所有目标在 from 到 to（含）之间的评分
+	ListUserRatings(ctx context.Context, ownerID int, from, to models.Date) ([]models.DailyRating, error)
+	// SumUserRatings 在一次查询中汇总用户所有目标中评分不低于 minRating 的评分次数和星数
+	SumUserRatings(ctx context.Context, ownerID, minRating int) (models.RatingTotal, error)
+	// SumRatingsByDay 在一次查询中按日期汇总满足条件的评分次数和星数，只返回有评分的日期，按日期升序
+	SumRatingsByDay(ctx context.Context, query HeatmapQuery) ([]models.DayTotal, error)
+	// ListJournal 按日期升序返回用户所有目标带备注的评分，from 和 to（含）为空时不限制
+	ListJournal(ctx context.Context, ownerID int, from, to *models.Date) ([]models.JournalEntry, error)
+	// SumRatingsForLeaderboard 按排名对象汇总未退出排行榜的用户的评分星数和次数，只返回有评分的项，不排序
+	SumRatingsForLeaderboard(ctx context.Context, query LeaderboardQuery) ([]models.LeaderboardEntry, error)
+}
+
+// RatingMerge 覆盖当天已有的评分时沿用哪些已有的字段，当天还没有评分时这些字段为空
+type RatingMerge struct {
+	KeepNote bool // 沿用已有的备注，忽略 rating.Note
+	KeepMood bool // 沿用已有的心情，忽略 rating.Mood
+}
+
+// HeatmapQuery 按日期汇总评分的条件
+type HeatmapQuery struct {
+	OwnerID  int         // 所属用户ID
+	From     models.Date // 起始日期（含）
+	To       models.Date // 结束日期（含）
+	GoalID   int         // 目标ID，为0时不过滤
+	Category string      // 类别，为空时不过滤
+}
+
+// LeaderboardQuery 排行榜的汇总条件
+type LeaderboardQuery struct {
+	By   string       // 排名对象：users、goals 或 categories
+	From *models.Date // 起始日期（含），为空时不限制
+	To   *models.Date // 结束日期（含），为空时不限制
+}
+
+// LedgerStore 定义星数流水的存储操作，流水只追加不修改
+type LedgerStore interface {
+	// AddStarTransaction 追加一笔星数流水，并回填该笔流水后目标的星数余额，关联的目标不存在时返回 ErrNotFound，
+	// 扣减星数使目标星数或所属用户的星数余额为负时返回 ErrInsufficientStars
+	AddStarTransaction(ctx context.Context, transaction *models.StarTransaction) error
+	// ListStarTransactions 按时间顺序返回目标的星数流水，并计算每笔流水后的余额
+	ListStarTransactions(ctx context.Context, goalID int) ([]models.StarTransaction, error)
+	// ListUserTransactions 按时间顺序返回用户所有目标在 since 到 until（不含）之间的星数流水，不计算余额
+	ListUserTransactions(ctx context.Context, ownerID int, since, until time.Time) ([]models.StarTransaction, error)
+}
+
+// CommentStore 定义评论的存储操作
+type CommentStore interface {
+	// CreateComment 保存新评论，并回填ID和创建时间
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	// GetComment 返回属于指定目标的评论，不存在时返回 ErrNotFound
+	GetComment(ctx context.Context, goalID int, id int) (*models.Comment, error)
+	// ListComments 按创建时间正序返回目标的所有评论，包括已删除评论的占位
+	ListComments(ctx context.Context, goalID int) ([]models.Comment, error)
+	// UpdateComment 修改属于指定目标的评论内容，被替换的内容保存到编辑历史，并回填编辑时间，
+	// 不存在时返回 ErrNotFound，已删除时返回 ErrCommentDeleted
+	UpdateComment(ctx context.Context, comment *models.Comment) error
+	// DeleteComment 删除属于指定目标的评论：有回复时清空内容和编辑历史，保留为占位，否则直接删除，
+	// 并删除因此不再有回复的已删除祖先评论；返回评论是否保留为占位，
+	// 不存在时返回 ErrNotFound，已删除时返回 ErrCommentDeleted
+	DeleteComment(ctx context.Context, goalID int, id int) (bool, error)
+	// ListCommentEdits 按编辑时间顺序返回评论的编辑历史
+	ListCommentEdits(ctx context.Context, commentID int) ([]models.CommentEdit, error)
+}
+
+// UserStore 定义用户账号的存储操作
+type UserStore interface {
+	// CreateUser 保存新用户，并回填ID和创建时间，用户名已存在时返回 ErrDuplicate
+	// 第一个注册的用户会认领所有尚无所属用户的目标、标签和类别
+	CreateUser(ctx context.Context, user *models.User) error
+	// GetUser 根据ID返回用户，不存在时返回 ErrNotFound
+	GetUser(ctx context.Context, id int) (*models.User, error)
+	// GetUserByUsername 根据用户名（不区分大小写）返回用户，不存在时返回 ErrNotFound
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// UpdateUserSettings 保存用户的时区（IANA 时区名，空字符串表示使用默认时区）和排行榜设置，不存在时返回 ErrNotFound
+	UpdateUserSettings(ctx context.Context, user *models.User) error
+}
+
+// AchievementStore 定义成就的存储操作
+type AchievementStore interface {
+	// AddAchievement 记录用户获得的成就，并回填ID和获得时间，用户已获得该成就时返回 ErrDuplicate
+	AddAchievement(ctx context.Context, achievement *models.Achievement) error
+	// ListAchievements 按获得时间顺序返回用户获得的所有成就
+	ListAchievements(ctx context.Context, userID int) ([]models.Achievement, error)
+}
+
+// WishStore 定义心愿和兑换记录的存储操作
+type WishStore interface {
+	// ListWishes 按创建顺序返回用户的所有心愿
+	ListWishes(ctx context.Context, ownerID int) ([]models.Wish, error)
+	// GetWish 根据ID返回心愿，不存在时返回 ErrNotFound
+	GetWish(ctx context.Context, id int) (*models.Wish, error)
+	// CreateWish 保存新心愿，并回填ID和时间戳
+	CreateWish(ctx context.Context, wish *models.Wish) error
+	// UpdateWish 更新心愿的标题、描述、价格和审批人，不影响已有的兑换记录，不存在时返回 ErrNotFound
+	UpdateWish(ctx context.Context, wish *models.Wish) error
+	// DeleteWish 删除心愿，兑换记录保留，不存在时返回 ErrNotFound
+	DeleteWish(ctx context.Context, id int) error
+	// RedeemWish 原子地检查用户的星数余额、扣除心愿价格并记录兑换，心愿设置了审批人时兑换等待审批，否则直接兑现；
+	// 心愿或用户不存在时返回 ErrNotFound，余额不足时返回 ErrInsufficientStars
+	RedeemWish(ctx context.Context, wishID, userID int) (*models.Redemption, error)
+	// ListRedemptions 按兑换时间倒序返回满足条件的兑换记录
+	ListRedemptions(ctx context.Context, query RedemptionQuery) ([]models.Redemption, error)
+	// GetRedemption 根据ID返回兑换记录，不存在时返回 ErrNotFound
+	GetRedemption(ctx context.Context, id int) (*models.Redemption, error)
+	// DecideRedemption 同意或拒绝等待审批的兑换，拒绝时退还扣除的星数，
+	// 不存在时返回 ErrNotFound，已经处理过时返回 ErrRedemptionDecided
+	DecideRedemption(ctx context.Context, id int, approve bool) error
+}
+
+// RedemptionQuery 兑换记录的过滤条件，UserID 和 ApproverID 至少指定一个
+type RedemptionQuery struct {
+	UserID     int    // 兑换的用户ID，为0时不过滤
+	ApproverID int    // 审批人的用户ID，为0时不过滤
+	Status     string // 兑换状态，为空时不过滤
+}
+
+// TagStore 定义标签的存储操作，目标的标签随 CreateGoal 和 UpdateGoal 保存
+type TagStore interface {
+	// ListTags 按名称顺序返回用户的所有标签及使用各标签的目标数
+	ListTags(ctx context.Context, ownerID int) ([]models.Tag, error)
+	// GetTag 根据ID返回标签，不存在时返回 ErrNotFound
+	GetTag(ctx context.Context, id int) (*models.Tag, error)
+	// RenameTag 重命名标签，不存在时返回 ErrNotFound，同一用户已有同名标签时返回 ErrDuplicate
+	RenameTag(ctx context.Context, id int, name string) error
+	// MergeTags 将 sourceIDs 中的标签合并到 targetID：使用源标签的目标改为使用目标标签，然后删除源标签
+	MergeTags(ctx context.Context, sourceIDs []int, targetID int) error
+	// DeleteTag 删除标签及其与目标的关联，不存在时返回 ErrNotFound
+	DeleteTag(ctx context.Context, id int) error
+}
+
+// CategoryStore 定义类别的存储操作，目标通过 category 字段按名称归入类别，
+// CreateGoal 和 UpdateGoal 使用尚不存在的类别名时自动创建该类别
+type CategoryStore interface {
+	// ListCategories 按排序值和名称顺序返回用户的所有类别，附带目标数、星数合计，
+	// 以及 since 当天及之后有评分的日期的评分情况（按日期升序，不补齐没有评分的日期）
+	ListCategories(ctx context.Context, ownerID int, since models.Date) ([]models.Category, error)
+	// GetCategory 根据ID返回类别（不含统计），不存在时返回 ErrNotFound
+	GetCategory(ctx context.Context, id int) (*models.Category, error)
+	// CreateCategory 创建类别，同一用户已有同名类别时返回 ErrDuplicate
+	CreateCategory(ctx context.Context, category *models.Category) error
+	// UpdateCategory 更新类别的名称、颜色、图标、排序值和每周星数预算，改名时同步修改该类别下目标的类别名，
+	// 不存在时返回 ErrNotFound，同一用户已有同名类别时返回 ErrDuplicate
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	// DeleteCategory 删除类别并清空该类别下目标的类别名，目标本身不受影响，不存在时返回 ErrNotFound
+	DeleteCategory(ctx context.Context, id int) error
+}
+
+// SearchStore 定义全文搜索的存储操作
+type SearchStore interface {
+	// SearchDocuments 返回用户的目标（标题、描述）和评论中包含任一检索词的候选文档，
+	// 目标和评论各最多 limit 条，相关度排序由 search.Rank 完成
+	SearchDocuments(ctx context.Context, ownerID int, terms []string, limit int) ([]search.Document, error)
+}
+
+// Store 聚合了所有存储接口，由具体的存储后端实现
+type Store interface {
+	GoalStore
+	RatingStore
+	LedgerStore
+	CommentStore
+	UserStore
+	AchievementStore
+	WishStore
+	TagStore
+	CategoryStore
+	SearchStore
+}
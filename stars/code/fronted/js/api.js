@@ -3,27 +3,60 @@
 // 基础URL配置
 const BASE_URL = 'http://localhost:8080';
 
+// 访问令牌在 localStorage 中的键
+const TOKEN_KEY = 'starpool_token';
+
+// 目标列表每页条数（后端允许的最大值）
+const GOALS_PAGE_SIZE = 100;
+
 // API端点
 const API_ENDPOINTS = {
+    // 认证相关端点
+    REGISTER: '/auth/register',
+    LOGIN: '/auth/login',
     GOALS: '/goals',
     GOAL_BY_ID: (id) => `/goals/${id}`,
-    GOALS_BY_CATEGORY: (category) => `/goals/category/${category}`,
+    GOALS_BY_CATEGORY: (category) => `/goals?category=${encodeURIComponent(category)}&limit=${GOALS_PAGE_SIZE}`,
     TOTAL_STARS: '/stars',
     DAILY_RATING: (id) => `/goals/${id}/daily-rating`,
     DAILY_RATINGS: (id) => `/goals/${id}/daily-ratings`,
+    HEATMAP: '/heatmap',
     // 评论相关端点
-    COMMENTS: (id) => `/goals/${id}/comments`
+    COMMENTS: (id) => `/goals/${id}/comments`,
+    // 类别相关端点
+    CATEGORIES: '/categories'
 };
 
+// 构造请求头，已登录时附带访问令牌
+function buildHeaders(headers = {}) {
+    const token = localStorage.getItem(TOKEN_KEY);
+    if (token) {
+        headers['Authorization'] = `Bearer ${token}`;
+    }
+    return headers;
+}
+
+// 检查响应状态，未登录或令牌过期时跳转到登录页
+function checkResponse(response) {
+    if (response.status === 401 && !window.location.pathname.endsWith('login.html')) {
+        localStorage.removeItem(TOKEN_KEY);
+        const inPages = window.location.pathname.includes('/pages/');
+        window.location.href = inPages ? 'login.html' : 'pages/login.html';
+    }
+    if (!response.ok) {
+        throw new Error(`HTTP error! status: ${response.status}`);
+    }
+}
+
 // HTTP请求工具函数
 const http = {
     // GET请求
     get: async (url) => {
         try {
-            const response = await fetch(BASE_URL + url);
-            if (!response.ok) {
-                throw new Error(`HTTP error! status: ${response.status}`);
-            }
+            const response = await fetch(BASE_URL + url, {
+                headers: buildHeaders()
+            });
+            checkResponse(response);
             return await response.json();
         } catch (error) {
             console.error('GET请求失败:', error);
@@ -36,15 +69,13 @@ const http = {
         try {
             const response = await fetch(BASE_URL + url, {
                 method: 'POST',
-                headers: {
+                headers: buildHeaders({
                     'Content-Type': 'application/json'
-                },
+                }),
                 body: JSON.stringify(data)
             });
             
-            if (!response.ok) {
-                throw new Error(`HTTP error! status: ${response.status}`);
-            }
+            checkResponse(response);
             
             return await response.json();
         } catch (error) {
@@ -58,15 +89,13 @@ const http = {
         try {
             const response = await fetch(BASE_URL + url, {
                 method: 'PUT',
-                headers: {
+                headers: buildHeaders({
                     'Content-Type': 'application/json'
-                },
+                }),
                 body: JSON.stringify(data)
             });
             
-            if (!response.ok) {
-                throw new Error(`HTTP error! status: ${response.status}`);
-            }
+            checkResponse(response);
             
             return await response.json();
         } catch (error) {
@@ -79,12 +108,11 @@ const http = {
     delete: async (url) => {
         try {
             const response = await fetch(BASE_URL + url, {
-                method: 'DELETE'
+                method: 'DELETE',
+                headers: buildHeaders()
             });
             
-            if (!response.ok) {
-                throw new Error(`HTTP error! status: ${response.status}`);
-            }
+            checkResponse(response);
             
             return await response.json();
         } catch (error) {
@@ -96,13 +124,13 @@ const http = {
 
 // 目标相关API
 const goalAPI = {
-    // 获取所有目标
-    getAllGoals: () => http.get(API_ENDPOINTS.GOALS),
+    // 获取目标列表，返回 { goals, next_cursor, total }
+    getAllGoals: () => http.get(`${API_ENDPOINTS.GOALS}?limit=${GOALS_PAGE_SIZE}`),
     
     // 根据ID获取目标
     getGoalById: (id) => http.get(API_ENDPOINTS.GOAL_BY_ID(id)),
     
-    // 根据类别获取目标
+    // 根据类别获取目标，返回 { goals, next_cursor, total }
     getGoalsByCategory: (category) => http.get(API_ENDPOINTS.GOALS_BY_CATEGORY(category)),
     
     // 创建新目标
@@ -120,12 +148,44 @@ const goalAPI = {
     // 为指定目标添加每日评分
     addDailyRating: (goalId, ratingData) => http.post(API_ENDPOINTS.DAILY_RATING(goalId), ratingData),
     
-    // 获取指定目标的所有每日评分记录
-    getDailyRatings: (goalId) => http.get(API_ENDPOINTS.DAILY_RATINGS(goalId)),
+    // 获取指定目标的每日评分汇总，返回 { from, to, granularity, buckets }
+    // params 可包含 from、to（YYYY-MM-DD）和 granularity（day、week、month），默认为最近7天按天汇总
+    getDailyRatings: (goalId, params = {}) => {
+        const query = new URLSearchParams(params).toString();
+        return http.get(API_ENDPOINTS.DAILY_RATINGS(goalId) + (query ? `?${query}` : ''));
+    },
+    
+    // 获取评分热力图，返回 { year, start, first_weekday, days, total, max, levels, stars }
+    // params 可包含 year、goal_id 和 category，默认为今年所有目标
+    getHeatmap: (params = {}) => {
+        const query = new URLSearchParams(params).toString();
+        return http.get(API_ENDPOINTS.HEATMAP + (query ? `?${query}` : ''));
+    },
     
     // 为指定目标创建评论
     createComment: (goalId, commentData) => http.post(API_ENDPOINTS.COMMENTS(goalId), commentData),
     
     // 获取指定目标的所有评论
     getComments: (goalId) => http.get(API_ENDPOINTS.COMMENTS(goalId))
-};
\ No newline at end of file
+};
+
+// 类别相关API
+const categoryAPI = {
+    // 获取类别列表，附带目标数、星数合计和最近7天的评分情况
+    getCategories: () => http.get(API_ENDPOINTS.CATEGORIES)
+};
+
+// 认证相关API
+const authAPI = {
+    // 注册新用户
+    register: (username, password) => http.post(API_ENDPOINTS.REGISTER, { username, password }),
+    
+    // 用户登录
+    login: (username, password) => http.post(API_ENDPOINTS.LOGIN, { username, password }),
+    
+    // 保存访问令牌
+    saveToken: (token) => localStorage.setItem(TOKEN_KEY, token),
+    
+    // 退出登录
+    logout: () => localStorage.removeItem(TOKEN_KEY)
+};
@@ -40,8 +40,8 @@ async function loadTotalStars() {
 // 加载所有目标
 async function loadAllGoals() {
     try {
-        const goals = await goalAPI.getAllGoals();
-        renderGoalList(goals);
+        const page = await goalAPI.getAllGoals();
+        renderGoalList(page.goals);
         // 同时更新总星数
         loadTotalStars();
         // 绑定星星点击事件
@@ -56,8 +56,8 @@ async function loadAllGoals() {
 // 根据类别加载目标
 async function loadGoalsByCategory(category) {
     try {
-        const goals = await goalAPI.getGoalsByCategory(category);
-        renderGoalList(goals);
+        const page = await goalAPI.getGoalsByCategory(category);
+        renderGoalList(page.goals);
         // 同时更新总星数
         loadTotalStars();
         // 绑定星星点击事件
@@ -72,14 +72,9 @@ async function loadGoalsByCategory(category) {
 // 更新目标星数
 async function updateGoalStars(goalId, stars) {
     try {
-        // 创建每日评分记录
-        const today = new Date();
-        today.setHours(0, 0, 0, 0); // 设置时间为当天的开始
-        
+        // 创建每日评分记录，不指定日期时后端按用户时区记为今天
         const ratingData = {
-            goal_id: goalId,
-            rating: stars,
-            date: today.toISOString(),
+            rating: stars
         };
         
         // 添加每日评分记录
@@ -182,22 +177,24 @@ async function loadGoalDetail(goalId) {
 // 加载并显示每日评分记录
 async function loadDailyRatings(goalId) {
     try {
-        const ratings = await goalAPI.getDailyRatings(goalId);
+        // 按天汇总的最近7天评分，没有评分的日期 count 为0
+        const data = await goalAPI.getDailyRatings(goalId);
+        const ratings = data.buckets.filter(bucket => bucket.count > 0);
         const container = document.getElementById(`daily-ratings-${goalId}`);
         
-        if (!ratings || ratings.length === 0) {
+        if (ratings.length === 0) {
             container.innerHTML = '<p>暂无每日评分记录</p>';
             return;
         }
         
-        // 按日期排序
-        ratings.sort((a, b) => new Date(b.date) - new Date(a.date));
+        // 按日期倒序排列
+        ratings.reverse();
         
         // 生成评分记录HTML
         const ratingsHTML = ratings.map(rating => `
             <div class="rating-item">
-                <span class="rating-date">${new Date(rating.date).toLocaleDateString()}</span>
-                <span class="rating-stars">${'⭐'.repeat(rating.rating)}</span>
+                <span class="rating-date">${rating.start}</span>
+                <span class="rating-stars">${'⭐'.repeat(rating.sum)}</span>
             </div>
         `).join('');
         
@@ -530,8 +527,41 @@ async function createGoal(event) {
     }
 }
 
+// 用后端管理的类别填充类别下拉框，没有类别时保留页面中的默认选项
+async function loadCategoryOptions() {
+    const selects = ['category', 'category-filter']
+        .map(id => document.getElementById(id))
+        .filter(select => select);
+    if (selects.length === 0) {
+        return;
+    }
+
+    try {
+        const categories = await categoryAPI.getCategories();
+        if (categories.length === 0) {
+            return;
+        }
+        selects.forEach(select => {
+            // 保留第一个"请选择"或"全部类别"选项
+            const first = select.options[0];
+            select.innerHTML = '';
+            select.appendChild(first);
+            categories.forEach(category => {
+                const option = document.createElement('option');
+                option.value = category.name;
+                option.textContent = category.icon ? `${category.icon} ${category.name}` : category.name;
+                select.appendChild(option);
+            });
+        });
+    } catch (error) {
+        console.error('加载类别失败:', error);
+    }
+}
+
 // 绑定类别筛选事件
 document.addEventListener('DOMContentLoaded', function() {
+    loadCategoryOptions();
+
     const categoryFilter = document.getElementById('category-filter');
     if (categoryFilter) {
         categoryFilter.addEventListener('change', function() {